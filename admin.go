@@ -0,0 +1,203 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+const announcePausedCursor = "announce_paused"
+
+var started = time.Now()
+
+type adminAction struct {
+	Name   string
+	Target bool
+	Run    func(ctx *commandContext, target string) (string, error)
+}
+
+var adminActions = []adminAction{
+	{Name: "unlink", Target: true, Run: adminUnlink},
+	{Name: "resync", Target: true, Run: adminResync},
+	{Name: "pause", Run: adminPause(true)},
+	{Name: "resume", Run: adminPause(false)},
+	{Name: "clearcache", Run: adminClearCache},
+	{Name: "status", Run: adminStatus},
+}
+
+func findAdminAction(name string) (adminAction, bool) {
+	for _, a := range adminActions {
+		if a.Name == strings.ToLower(name) {
+			return a, true
+		}
+	}
+
+	return adminAction{}, false
+}
+
+func adminActionNames() []string {
+	names := []string{}
+
+	for _, a := range adminActions {
+		names = append(names, a.Name)
+	}
+
+	return names
+}
+
+func adminHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	m := ctx.msg
+
+	if !ctx.perms.IsAdmin(ctx.nicks, m) {
+		log.Printf("admin: denied %s!%s@%s running %q\n", m.Nick, m.User, m.Host, m.Args)
+		return ctx.sprintf("Error: admin commands are restricted")
+	}
+
+	a, ok := findAdminAction(args.Arg("action"))
+	if !ok {
+		return ctx.sprintf("Error: unknown admin action %s, available: %s", args.Arg("action"), strings.Join(adminActionNames(), ", "))
+	}
+
+	target := args.Arg("nick")
+
+	if a.Target && target == "" {
+		return ctx.sprintf("Error: %s needs a nick", a.Name)
+	}
+
+	if !a.Target && target != "" {
+		return ctx.sprintf("Error: %s does not take a nick", a.Name)
+	}
+
+	log.Printf("admin: %s!%s@%s ran %s %s\n", m.Nick, m.User, m.Host, a.Name, target)
+
+	return a.Run(ctx, target)
+}
+
+func adminUnlink(ctx *commandContext, nick string) (string, error) {
+	key := ctx.nickKey(nick)
+
+	rec, err := ctx.store.GetUser(key)
+	if err != nil {
+		return "", err
+	}
+
+	if rec.User == "" {
+		return ctx.sprintf("%s is not linked to a retroachievements user", nick)
+	}
+
+	if err := ctx.store.DeleteUser(key); err != nil {
+		return "", err
+	}
+
+	return ctx.sprintf("unlinked %s from %s", nick, rec.User)
+}
+
+func adminResync(ctx *commandContext, nick string) (string, error) {
+	key := ctx.nickKey(nick)
+
+	rec, err := ctx.store.GetUser(key)
+	if err != nil {
+		return "", err
+	}
+
+	if rec.User == "" {
+		return ctx.sprintf("%s is not linked to a retroachievements user", nick)
+	}
+
+	profile, err := raUserProfile(ctx.client, rec.User)
+	if err != nil {
+		return "", err
+	}
+
+	if profile.ID == 0 || profile.User == "" {
+		return ctx.sprintf("Error: retroachievements user %s not found", rec.User)
+	}
+
+	err = ctx.store.UpdateUser(key, func(r *userRecord) {
+		r.User = profile.User
+		r.ID = profile.ID
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if rec.Verified() && rec.ID != profile.ID {
+		return ctx.sprintf("resynced %s to %s, the account ID changed so verification was reset", nick, profile.User)
+	}
+
+	return ctx.sprintf("resynced %s to %s", nick, profile.User)
+}
+
+func adminPause(pause bool) func(ctx *commandContext, target string) (string, error) {
+	return func(ctx *commandContext, target string) (string, error) {
+		value := "0"
+		if pause {
+			value = "1"
+		}
+
+		if err := ctx.store.SetCursor(announcePausedCursor, value); err != nil {
+			return "", err
+		}
+
+		if pause {
+			return ctx.sprintf("announcements paused, this applies once achievement announcements are available")
+		}
+
+		return ctx.sprintf("announcements resumed, this applies once achievement announcements are available")
+	}
+}
+
+func adminClearCache(ctx *commandContext, target string) (string, error) {
+	n := consoleCache.Len() + gameListCache.Len()
+
+	consoleCache.Clear()
+	gameListCache.Clear()
+
+	return ctx.sprintf("cleared %d cached entries", n)
+}
+
+func adminStatus(ctx *commandContext, target string) (string, error) {
+	nicks, err := ctx.store.Nicks()
+	if err != nil {
+		return "", err
+	}
+
+	linked, verified := 0, 0
+
+	for _, nick := range nicks {
+		rec, err := ctx.store.GetUser(nick)
+		if err != nil {
+			return "", err
+		}
+
+		if rec.User != "" {
+			linked++
+		}
+
+		if rec.Verified() {
+			verified++
+		}
+	}
+
+	channels, err := ctx.store.Channels()
+	if err != nil {
+		return "", err
+	}
+
+	paused, err := ctx.store.Cursor(announcePausedCursor)
+	if err != nil {
+		return "", err
+	}
+
+	args := []any{
+		now().Sub(started).Round(time.Second),
+		linked, verified, len(channels),
+		consoleCache.Len() + gameListCache.Len(),
+	}
+
+	if paused == "1" {
+		return ctx.sprintf("up %s | %d linked users (%d verified) | %d configured channels | %d cached entries | announcements paused", args...)
+	}
+
+	return ctx.sprintf("up %s | %d linked users (%d verified) | %d configured channels | %d cached entries | announcements on", args...)
+}
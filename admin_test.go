@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandler(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	started = now().Add(-90 * time.Minute)
+
+	consoleCache.Clear()
+	gameListCache.Clear()
+	consoleCache.Set("consoles", []Console{{ID: 1, Name: "Genesis/Mega Drive"}})
+
+	json := openTestFile(t, "API_GetUserProfile", "profile.json")
+
+	client := req.C()
+	httpmock.ActivateNonDefault(client.GetClient())
+	httpmock.RegisterResponder("GET", raUserProfileURL, func(request *http.Request) (*http.Response, error) {
+		resp := httpmock.NewBytesResponse(http.StatusOK, json)
+		return resp, nil
+	})
+
+	store := newMemoryStore()
+	linkUser(t, store, "alice", "Alice", 1)
+	linkUser(t, store, "shark", "sharktamer", 1)
+	assert.Nil(t, store.UpdateUser("shark", func(rec *userRecord) { rec.VerifiedID = 1 }))
+	assert.Nil(t, store.SetChannelSetting("#ra", chanColours, settingOff))
+
+	r := defaultCommands()
+	ctx := testContext(client, store, "")
+	ctx.commands = r
+	ctx.perms = permissions{Admins: []string{"*!*@admin.example"}}
+
+	steps := []struct {
+		host     string
+		args     string
+		expected string
+	}{
+		{
+			host:     "elsewhere.example",
+			args:     "admin status",
+			expected: "Error: admin commands are restricted",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin status",
+			expected: "up 1h30m0s | 2 linked users (1 verified) | 1 configured channels | 1 cached entries | announcements on",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin nope",
+			expected: "Error: unknown admin action nope, available: unlink, resync, pause, resume, clearcache, status",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin unlink",
+			expected: "Error: unlink needs a nick",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin pause now",
+			expected: "Error: pause does not take a nick",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin unlink Alice",
+			expected: "unlinked Alice from Alice",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin unlink Alice",
+			expected: "Alice is not linked to a retroachievements user",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin resync shark",
+			expected: "resynced shark to Sharktamer, the account ID changed so verification was reset",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin pause",
+			expected: "announcements paused, this applies once achievement announcements are available",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin clearcache",
+			expected: "cleared 1 cached entries",
+		},
+		{
+			host:     "admin.example",
+			args:     "admin status",
+			expected: "up 1h30m0s | 1 linked users (0 verified) | 1 configured channels | 0 cached entries | announcements paused",
+		},
+	}
+
+	for _, s := range steps {
+		ctx.msg = &gowon.Message{Nick: "boss", User: "boss", Host: s.host, Args: s.args}
+
+		out, err := r.Dispatch(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, s.expected, out, s.args)
+	}
+
+	rec, err := store.GetUser("shark")
+	assert.Nil(t, err)
+	assert.Equal(t, userRecord{User: "Sharktamer", ID: 119117, VerifiedID: 1}, rec)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	summary := openTestFile(t, "API_GetUserSummary", "summary.json")
+
+	cases := map[string]struct {
+		path     string
+		status   int
+		template string
+		message  string
+		errMsg   string
+		points   float64
+	}{
+		"points": {
+			path:     "/api/v1/points?args=sharktamer",
+			status:   http.StatusOK,
+			template: "points",
+			points:   509,
+		},
+		"linked nick": {
+			path:     "/api/v1/p?nick=shark",
+			status:   http.StatusOK,
+			template: "points",
+			points:   509,
+		},
+		"message only": {
+			path:    "/api/v1/points?args=a+b+c",
+			status:  http.StatusOK,
+			message: "Error: unexpected argument b (usage: points [user])",
+		},
+		"not an api command": {
+			path:   "/api/v1/admin?args=status",
+			status: http.StatusNotFound,
+			errMsg: "unknown command admin",
+		},
+		"unknown command": {
+			path:   "/api/v1/nope",
+			status: http.StatusNotFound,
+			errMsg: "unknown command nope",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", raUserSummaryURL, httpmock.NewBytesResponder(http.StatusOK, summary))
+			defer httpmock.DeactivateAndReset()
+
+			store := newMemoryStore()
+			linkUser(t, store, "shark", "sharktamer", 119117)
+
+			srv := &server{
+				client:   client,
+				store:    store,
+				commands: defaultCommands(),
+				nicks:    nickMapper{Casemapping: casemappingRFC1459},
+			}
+
+			r := gin.New()
+			r.GET("/api/v1/:command", srv.apiHandler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.status, w.Code)
+
+			var resp struct {
+				Template string         `json:"template"`
+				Data     map[string]any `json:"data"`
+				Message  string         `json:"message"`
+				Error    string         `json:"error"`
+			}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.template, resp.Template)
+			assert.Equal(t, tc.message, resp.Message)
+			assert.Equal(t, tc.errMsg, resp.Error)
+
+			if tc.template != "" {
+				assert.Equal(t, "sharktamer", resp.Data["User"])
+				assert.Equal(t, tc.points, resp.Data["TotalPoints"])
+			}
+		})
+	}
+}
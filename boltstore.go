@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	preferencesBucket = []byte("preferences")
+	channelsBucket    = []byte("channels")
+	historyBucket     = []byte("history")
+	cursorsBucket     = []byte("cursors")
+
+	buckets = [][]byte{
+		metaBucket,
+		usersBucket,
+		preferencesBucket,
+		channelsBucket,
+		historyBucket,
+		cursorsBucket,
+	}
+
+	nickBuckets = [][]byte{
+		usersBucket,
+		preferencesBucket,
+		historyBucket,
+	}
+)
+
+var boltOpenTimeout = 5 * time.Second
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0666, &bolt.Options{Timeout: boltOpenTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("database %s in use, stop the running module first", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newBoltStore(db, path)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func newBoltStore(db *bolt.DB, path string) (*boltStore, error) {
+	if err := runMigrations(db, path); err != nil {
+		return nil, err
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) get(bucket []byte, key string, v any) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		j := tx.Bucket(bucket).Get([]byte(key))
+		if j == nil {
+			return nil
+		}
+
+		return json.Unmarshal(j, v)
+	})
+}
+
+func (s *boltStore) update(bucket []byte, key string, v any, f func() bool) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		if j := b.Get([]byte(key)); j != nil {
+			if err := json.Unmarshal(j, v); err != nil {
+				return err
+			}
+		}
+
+		if !f() {
+			return b.Delete([]byte(key))
+		}
+
+		j, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(key), j)
+	})
+}
+
+func (s *boltStore) GetUser(nick string) (rec userRecord, err error) {
+	err = s.get(usersBucket, nick, &rec)
+	return rec, err
+}
+
+func (s *boltStore) UpdateUser(nick string, f func(*userRecord)) error {
+	rec := userRecord{}
+
+	return s.update(usersBucket, nick, &rec, func() bool {
+		f(&rec)
+		return true
+	})
+}
+
+func (s *boltStore) DeleteUser(nick string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).Delete([]byte(nick))
+	})
+}
+
+func (s *boltStore) Preferences(nick string) (map[string]string, error) {
+	prefs := map[string]string{}
+	err := s.get(preferencesBucket, nick, &prefs)
+	return prefs, err
+}
+
+func (s *boltStore) SetPreference(nick, key, value string) error {
+	prefs := map[string]string{}
+
+	return s.update(preferencesBucket, nick, &prefs, func() bool {
+		prefs[key] = value
+		return true
+	})
+}
+
+func (s *boltStore) DeletePreference(nick, key string) error {
+	prefs := map[string]string{}
+
+	return s.update(preferencesBucket, nick, &prefs, func() bool {
+		delete(prefs, key)
+		return len(prefs) > 0
+	})
+}
+
+func (s *boltStore) Channels() ([]string, error) {
+	channels := []string{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(channelsBucket).ForEach(func(k, v []byte) error {
+			channels = append(channels, string(k))
+			return nil
+		})
+	})
+
+	return channels, err
+}
+
+func (s *boltStore) ChannelSettings(channel string) (map[string]string, error) {
+	settings := map[string]string{}
+	err := s.get(channelsBucket, channel, &settings)
+	return settings, err
+}
+
+func (s *boltStore) SetChannelSetting(channel, key, value string) error {
+	settings := map[string]string{}
+
+	return s.update(channelsBucket, channel, &settings, func() bool {
+		settings[key] = value
+		return true
+	})
+}
+
+func (s *boltStore) DeleteChannelSetting(channel, key string) error {
+	settings := map[string]string{}
+
+	return s.update(channelsBucket, channel, &settings, func() bool {
+		delete(settings, key)
+		return len(settings) > 0
+	})
+}
+
+func (s *boltStore) AddHistory(nick string, e historyEntry) error {
+	h := []historyEntry{}
+
+	return s.update(historyBucket, nick, &h, func() bool {
+		h = trimHistory(append(h, e))
+		return true
+	})
+}
+
+func (s *boltStore) History(nick string) ([]historyEntry, error) {
+	h := []historyEntry{}
+	err := s.get(historyBucket, nick, &h)
+	return h, err
+}
+
+func (s *boltStore) Cursor(name string) (value string, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		value = string(tx.Bucket(cursorsBucket).Get([]byte(name)))
+		return nil
+	})
+	return value, err
+}
+
+func (s *boltStore) SetCursor(name, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cursorsBucket).Put([]byte(name), []byte(value))
+	})
+}
+
+func (s *boltStore) Cursors() (map[string]string, error) {
+	cursors := map[string]string{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(cursorsBucket).ForEach(func(k, v []byte) error {
+			cursors[string(k)] = string(v)
+			return nil
+		})
+	})
+
+	return cursors, err
+}
+
+func (s *boltStore) Nicks() ([]string, error) {
+	set := map[string]bool{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, name := range nickBuckets {
+			err := tx.Bucket(name).ForEach(func(k, v []byte) error {
+				set[string(k)] = true
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	nicks := []string{}
+	for n := range set {
+		nicks = append(nicks, n)
+	}
+	sort.Strings(nicks)
+
+	return nicks, err
+}
+
+func (s *boltStore) RenameNick(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range nickBuckets {
+			b := tx.Bucket(name)
+
+			v := b.Get([]byte(from))
+			if v == nil {
+				continue
+			}
+			v = append([]byte{}, v...)
+
+			if err := b.Delete([]byte(from)); err != nil {
+				return err
+			}
+
+			if b.Get([]byte(to)) != nil {
+				continue
+			}
+
+			if err := b.Put([]byte(to), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Clear() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, b := range buckets {
+			if bytes.Equal(b, metaBucket) {
+				continue
+			}
+
+			if err := tx.DeleteBucket(b); err != nil {
+				return err
+			}
+
+			if _, err := tx.CreateBucket(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Backup(path string) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	chanDisabled  = "disabled"
+	chanAnnounce  = "announce"
+	chanColours   = "colours"
+	chanDetail    = "detail"
+	chanMaxLength = "maxlength"
+	chanTheme     = "theme"
+	chanLanguage  = "language"
+
+	settingOn  = "on"
+	settingOff = "off"
+
+	minChannelLineLength = 50
+	truncatedSuffix      = " ..."
+	clearTag             = "{" + colourClear + "}"
+
+	maxOutputLines = 4
+)
+
+var (
+	colourTag = regexp.MustCompile(`\{[a-z]+(,[a-z]+)?\}`)
+
+	alwaysEnabledCommands = []string{"admin", "chanset", "help"}
+
+	outputSeparators = []string{achievementSeparator, " | ", ", "}
+)
+
+type channelConfig struct {
+	Disabled  []string
+	Announce  bool
+	Colours   bool
+	Detail    string
+	MaxLength int
+	Theme     string
+	Language  string
+}
+
+func defaultChannelConfig() channelConfig {
+	return channelConfig{
+		Announce:  true,
+		Colours:   true,
+		MaxLength: maxLineLength,
+	}
+}
+
+func (c channelConfig) Enabled(name string) bool {
+	for _, d := range c.Disabled {
+		if d == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c channelConfig) Format(out string) string {
+	if !c.Colours {
+		out = stripColours(out)
+	}
+
+	lines := splitLines(out, c.MaxLength)
+
+	if len(lines) > maxOutputLines {
+		lines = lines[:maxOutputLines]
+		lines[maxOutputLines-1] = truncateLine(lines[maxOutputLines-1]+truncatedSuffix, c.MaxLength)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func stripColours(s string) string {
+	return colourTag.ReplaceAllString(s, "")
+}
+
+func truncateLine(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+
+	cut := n - len(truncatedSuffix)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	out := s[:cut]
+
+	if open := strings.LastIndex(out, "{"); open > strings.LastIndex(out, "}") {
+		out = out[:open]
+	}
+
+	return out + truncatedSuffix
+}
+
+func splitLines(s string, n int) []string {
+	if n <= len(clearTag) {
+		return []string{s}
+	}
+
+	lines := []string{}
+
+	for len(s) > n {
+		line, rest := splitLine(s, n)
+
+		if len(rest) >= len(s) {
+			cut := n
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+
+			line, rest = s[:cut], s[cut:]
+		}
+
+		lines = append(lines, line)
+		s = rest
+	}
+
+	return append(lines, s)
+}
+
+func splitLine(s string, n int) (string, string) {
+	cut, skip := findCut(s, n)
+	tag := openColour(s[:cut])
+
+	if tag != "" && cut+len(clearTag) > n {
+		cut, skip = findCut(s, n-len(clearTag))
+		tag = openColour(s[:cut])
+	}
+
+	line, rest := s[:cut], s[cut+skip:]
+
+	if tag != "" {
+		line += clearTag
+		rest = tag + rest
+	}
+
+	return line, rest
+}
+
+func findCut(s string, n int) (int, int) {
+	cut, skip := -1, 0
+
+	for _, sep := range outputSeparators {
+		if i := strings.LastIndex(s[:min(len(s), n+len(sep))], sep); i > cut {
+			cut, skip = i, len(sep)
+		}
+	}
+
+	if cut >= n/2 {
+		return cut, skip
+	}
+
+	if cut = strings.LastIndex(s[:n+1], " "); cut >= n/2 {
+		return cut, 1
+	}
+
+	cut = n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	if open := strings.LastIndex(s[:cut], "{"); open > strings.LastIndex(s[:cut], "}") && open > 0 {
+		cut = open
+	}
+
+	return cut, 0
+}
+
+func openColour(s string) string {
+	tags := colourTag.FindAllString(s, -1)
+
+	if len(tags) == 0 || tags[len(tags)-1] == clearTag {
+		return ""
+	}
+
+	return tags[len(tags)-1]
+}
+
+func onOffSetting(set func(c *channelConfig, on bool)) func(*channelConfig, string) error {
+	return choiceSetting(func(c *channelConfig, v string) {
+		set(c, v == settingOn)
+	}, settingOn, settingOff)
+}
+
+var channelSettingSpecs = []settingSpec[channelConfig]{
+	{
+		Name:   chanDisabled,
+		Values: "comma separated commands",
+		Apply: func(c *channelConfig, v string) error {
+			disabled := []string{}
+
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					disabled = append(disabled, name)
+				}
+			}
+
+			if len(disabled) == 0 {
+				return localeErrorf("at least one command is needed")
+			}
+
+			c.Disabled = disabled
+			return nil
+		},
+	},
+	{
+		Name:   chanAnnounce,
+		Values: "on|off, applies once achievement announcements are available",
+		Apply: onOffSetting(func(c *channelConfig, on bool) {
+			c.Announce = on
+		}),
+	},
+	{
+		Name:   chanColours,
+		Values: "on|off",
+		Apply: onOffSetting(func(c *channelConfig, on bool) {
+			c.Colours = on
+		}),
+	},
+	{
+		Name:   chanDetail,
+		Values: "full|short",
+		Apply: choiceSetting(func(c *channelConfig, v string) {
+			c.Detail = v
+		}, detailFull, detailShort),
+	},
+	{
+		Name:   chanMaxLength,
+		Values: fmt.Sprintf("%d-%d", minChannelLineLength, maxLineLength),
+		Apply: func(c *channelConfig, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < minChannelLineLength || n > maxLineLength {
+				return localeErrorf("max length must be between %d and %d", minChannelLineLength, maxLineLength)
+			}
+
+			c.MaxLength = n
+			return nil
+		},
+	},
+	{
+		Name:   chanTheme,
+		Values: "theme name, e.g. monochrome",
+		Apply: func(c *channelConfig, v string) error {
+			t, err := themeSetting(v)
+			if err != nil {
+				return err
+			}
+
+			c.Theme = t.Name
+			return nil
+		},
+	},
+	{
+		Name:   chanLanguage,
+		Values: "language code, e.g. de",
+		Apply: func(c *channelConfig, v string) error {
+			l, err := localeSetting(v)
+			if err != nil {
+				return err
+			}
+
+			c.Language = l.Name
+			return nil
+		},
+	},
+}
+
+func parseChannelConfig(stored map[string]string) channelConfig {
+	c := defaultChannelConfig()
+	applySettings(channelSettingSpecs, &c, stored)
+	return c
+}
+
+func isChannel(dest string) bool {
+	return strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "&")
+}
+
+func disabledCommands(commands *commandRegistry, value string) (string, error) {
+	spec, _ := findSetting(channelSettingSpecs, chanDisabled)
+
+	c := channelConfig{}
+	if err := spec.Apply(&c, value); err != nil {
+		return "", err
+	}
+
+	names := []string{}
+
+	for _, name := range c.Disabled {
+		cmd, ok := commands.Find(name)
+		if !ok {
+			return "", localeErrorf("unknown command %s", name)
+		}
+
+		for _, always := range alwaysEnabledCommands {
+			if cmd.Name == always {
+				return "", localeErrorf("%s can not be disabled", cmd.Name)
+			}
+		}
+
+		names = append(names, cmd.Name)
+	}
+
+	return strings.Join(names, ","), nil
+}
+
+func chansetHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	channel := ctx.msg.Dest
+
+	if !isChannel(channel) {
+		return ctx.sprintf("Error: chanset can only be used in a channel")
+	}
+
+	key := ctx.channelKey()
+
+	stored, err := ctx.store.ChannelSettings(key)
+	if err != nil {
+		return "", err
+	}
+
+	prefs, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	name := args.Arg("key")
+
+	if name == "" {
+		set := storedSettings(channelSettingSpecs, stored)
+
+		if len(set) == 0 {
+			return ctx.sprintf("%s has no settings set, available: %s", channel, strings.Join(settingValues(prefs.Locale, channelSettingSpecs), ", "))
+		}
+
+		return ctx.sprintf("%s's settings: %s", channel, strings.Join(set, ", "))
+	}
+
+	s, ok := findSetting(channelSettingSpecs, name)
+	if !ok {
+		return ctx.sprintf("Error: unknown setting %s, available: %s", name, strings.Join(settingNames(channelSettingSpecs), ", "))
+	}
+
+	value := args.Arg("value")
+
+	if value == "" {
+		if v, ok := stored[s.Name]; ok {
+			return ctx.sprintf("%s's %s setting is %s", channel, s.Name, v)
+		}
+
+		return ctx.sprintf("%s's %s setting is not set (%s)", channel, s.Name, prefs.Locale.Translate(s.Values))
+	}
+
+	if !ctx.perms.IsChanop(ctx.nicks, ctx.nicks.MessageNetwork(ctx.msg), channel, ctx.msg) {
+		return ctx.sprintf("Error: only channel operators can change %s's settings", channel)
+	}
+
+	if value == prefDefault {
+		if err := ctx.store.DeleteChannelSetting(key, s.Name); err != nil {
+			return "", err
+		}
+
+		return ctx.sprintf("reset %s's %s setting", channel, s.Name)
+	}
+
+	if s.Name == chanDisabled {
+		value, err = disabledCommands(ctx.commands, value)
+	} else {
+		c := defaultChannelConfig()
+		err = s.Apply(&c, value)
+	}
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	if err := ctx.store.SetChannelSetting(key, s.Name, value); err != nil {
+		return "", err
+	}
+
+	return ctx.sprintf("set %s's %s setting to %s", channel, s.Name, value)
+}
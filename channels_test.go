@@ -0,0 +1,335 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateLine(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		n        int
+		expected string
+	}{
+		"short": {
+			in:       "hello",
+			n:        10,
+			expected: "hello",
+		},
+		"truncated": {
+			in:       "hello world, how are you",
+			n:        12,
+			expected: "hello wo ...",
+		},
+		"colour tag not split": {
+			in:       "hello {green}world{clear}",
+			n:        12,
+			expected: "hello  ...",
+		},
+		"multibyte rune not split": {
+			in:       "ポケモン ポケモン",
+			n:        9,
+			expected: "ポ ...",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, truncateLine(tc.in, tc.n))
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		n        int
+		expected []string
+	}{
+		"short": {
+			in:       "hello",
+			n:        50,
+			expected: []string{"hello"},
+		},
+		"field separator": {
+			in:       "user | first field is here | second field is here | third field",
+			n:        50,
+			expected: []string{"user | first field is here | second field is here", "third field"},
+		},
+		"list separator": {
+			in:       "user's games: Sonic, Tetris, Metroid, Castlevania, Zelda, Kirby",
+			n:        50,
+			expected: []string{"user's games: Sonic, Tetris, Metroid, Castlevania", "Zelda, Kirby"},
+		},
+		"colour carried over": {
+			in:       "user's games: {green}Sonic, Tetris, Metroid, Castlevania, Zelda{clear}",
+			n:        50,
+			expected: []string{"user's games: {green}Sonic, Tetris, Metroid{clear}", "{green}Castlevania, Zelda{clear}"},
+		},
+		"no separator": {
+			in:       "abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghij",
+			n:        50,
+			expected: []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwx", "yzabcdefghij"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, splitLines(tc.in, tc.n))
+		})
+	}
+}
+
+func TestSplitLinesAdjacentTags(t *testing.T) {
+	in := "x | {lightgreen,lightgrey}{lightgreen,lightgrey}{lightgreen,lightgrey}é ||  ||  |  {red}, é{lightgreen,lightgrey} ,  {clear},  ||  || x"
+
+	lines := splitLines(in, 50)
+
+	assert.Greater(t, len(lines), 1)
+	for _, l := range lines {
+		assert.LessOrEqual(t, len(l), 50, l)
+	}
+}
+
+func TestChannelConfigFormatMaxLines(t *testing.T) {
+	c := defaultChannelConfig()
+	c.MaxLength = minChannelLineLength
+
+	items := []string{}
+	for i := 0; i < 30; i++ {
+		items = append(items, "item number")
+	}
+
+	lines := strings.Split(c.Format(strings.Join(items, ", ")), "\n")
+
+	assert.Len(t, lines, maxOutputLines)
+	assert.True(t, strings.HasSuffix(lines[maxOutputLines-1], truncatedSuffix))
+
+	for _, l := range lines {
+		assert.LessOrEqual(t, len(l), minChannelLineLength)
+	}
+}
+
+func TestStripColours(t *testing.T) {
+	assert.Equal(t, "user | Points: 1 | {not a colour}", stripColours("user | {green}Points: 1{clear} | {not a colour}"))
+}
+
+func TestParseChannelConfig(t *testing.T) {
+	c := parseChannelConfig(map[string]string{
+		"disabled":  "random,last",
+		"announce":  "off",
+		"colours":   "off",
+		"detail":    "short",
+		"maxlength": "nope",
+	})
+
+	assert.Equal(t, channelConfig{
+		Disabled:  []string{"random", "last"},
+		Announce:  false,
+		Colours:   false,
+		Detail:    detailShort,
+		MaxLength: maxLineLength,
+	}, c)
+
+	assert.False(t, c.Enabled("random"))
+	assert.True(t, c.Enabled("points"))
+}
+
+func TestChansetHandler(t *testing.T) {
+	store := newMemoryStore()
+	r := defaultCommands()
+
+	ctx := testContext(nil, store, "Op")
+	ctx.commands = r
+	ctx.msg.Dest = "#RA"
+	ctx.perms = permissions{Chanops: []string{"#ra:op"}}
+
+	steps := []struct {
+		nick     string
+		dest     string
+		args     string
+		expected string
+	}{
+		{
+			nick:     "Op",
+			dest:     "Op",
+			args:     "chanset",
+			expected: "Error: chanset can only be used in a channel",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset",
+			expected: "#RA has no settings set, available: disabled (comma separated commands), announce (on|off, applies once achievement announcements are available), colours (on|off), detail (full|short), maxlength (50-400), theme (theme name, e.g. monochrome), language (language code, e.g. de)",
+		},
+		{
+			nick:     "someone",
+			dest:     "#RA",
+			args:     "chanset colours off",
+			expected: "Error: only channel operators can change #RA's settings",
+		},
+		{
+			nick:     "Op",
+			dest:     "#other",
+			args:     "chanset colours off",
+			expected: "Error: only channel operators can change #other's settings",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset colours off",
+			expected: "set #RA's colours setting to off",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset disabled r,l",
+			expected: "set #RA's disabled setting to random,last",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset disabled help",
+			expected: "Error: help can not be disabled",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset maxlength 10",
+			expected: "Error: max length must be between 50 and 400",
+		},
+		{
+			nick:     "someone",
+			dest:     "#ra",
+			args:     "r",
+			expected: "random is disabled in #ra",
+		},
+		{
+			nick:     "someone",
+			dest:     "#ra",
+			args:     "chanset",
+			expected: "#ra's settings: disabled=random,last, colours=off",
+		},
+		{
+			nick:     "Op",
+			dest:     "#RA",
+			args:     "chanset disabled default",
+			expected: "reset #RA's disabled setting",
+		},
+		{
+			nick:     "someone",
+			dest:     "#ra",
+			args:     "chanset disabled",
+			expected: "#ra's disabled setting is not set (comma separated commands)",
+		},
+	}
+
+	for _, s := range steps {
+		ctx.msg = &gowon.Message{Nick: s.nick, Dest: s.dest, Args: s.args}
+
+		out, err := r.Dispatch(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, s.expected, out, s.args)
+	}
+}
+
+func TestDispatchChannelFormatting(t *testing.T) {
+	store := newMemoryStore()
+	assert.Nil(t, store.SetChannelSetting("#ra", chanColours, settingOff))
+	assert.Nil(t, store.SetChannelSetting("#ra", chanDetail, detailShort))
+
+	r := newCommandRegistry(&command{
+		commandSpec: commandSpec{Name: "echo"},
+		Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+			p, err := ctx.preferences()
+			return colourString(p.Detail, "green"), err
+		},
+	})
+
+	cases := map[string]struct {
+		nick     string
+		dest     string
+		expected string
+	}{
+		"channel defaults": {
+			nick:     "nick",
+			dest:     "#RA",
+			expected: "short",
+		},
+		"nick preference wins": {
+			nick:     "full",
+			dest:     "#RA",
+			expected: "full",
+		},
+		"private message": {
+			nick:     "nick",
+			dest:     "nick",
+			expected: "{green}full{clear}",
+		},
+	}
+
+	assert.Nil(t, store.SetPreference("full", prefDetail, detailFull))
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(nil, store, tc.nick)
+			ctx.msg.Dest = tc.dest
+			ctx.msg.Args = "echo"
+
+			out, err := r.Dispatch(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestDispatchChannelLanguage(t *testing.T) {
+	store := newMemoryStore()
+	assert.Nil(t, store.SetChannelSetting("#ra", chanLanguage, "es"))
+	assert.Nil(t, store.SetPreference("german", prefLanguage, "de"))
+
+	r := newCommandRegistry(&command{
+		commandSpec: commandSpec{Name: "echo"},
+		Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+			p, err := ctx.preferences()
+			return p.Locale.Name, err
+		},
+	})
+
+	cases := map[string]struct {
+		nick     string
+		dest     string
+		expected string
+	}{
+		"channel language": {
+			nick:     "nick",
+			dest:     "#RA",
+			expected: "es",
+		},
+		"nick preference wins": {
+			nick:     "german",
+			dest:     "#RA",
+			expected: "de",
+		},
+		"private message": {
+			nick:     "nick",
+			dest:     "nick",
+			expected: "en",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(nil, store, tc.nick)
+			ctx.msg.Dest = tc.dest
+			ctx.msg.Args = "echo"
+
+			out, err := r.Dispatch(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/imroc/req/v3"
+)
+
+type commandContext struct {
+	client   *req.Client
+	store    Store
+	msg      *gowon.Message
+	commands *commandRegistry
+	nicks    nickMapper
+	perms    permissions
+	channel  channelConfig
+	capture  *capturedOutput
+}
+
+func (ctx *commandContext) nickKey(nick string) string {
+	return string(ctx.nicks.Key(ctx.nicks.MessageNetwork(ctx.msg), nick))
+}
+
+func (ctx *commandContext) channelKey() string {
+	return ctx.nickKey(ctx.msg.Dest)
+}
+
+func (ctx *commandContext) preferences() (Preferences, error) {
+	stored, err := ctx.store.Preferences(ctx.nickKey(ctx.msg.Nick))
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	p := parsePreferences(stored)
+	p.capture = ctx.capture
+
+	if _, ok := stored[prefDetail]; !ok && ctx.channel.Detail != "" {
+		p.Detail = ctx.channel.Detail
+	}
+
+	if _, ok := stored[prefTheme]; !ok && ctx.channel.Theme != "" {
+		if t, ok := findTheme(ctx.channel.Theme); ok {
+			p.Theme = t
+		}
+	}
+
+	if _, ok := stored[prefLanguage]; !ok && ctx.channel.Language != "" {
+		if l, ok := findLocale(ctx.channel.Language); ok {
+			p.Locale = l
+		}
+	}
+
+	return p, nil
+}
+
+func (ctx *commandContext) errorReply() string {
+	p, err := ctx.preferences()
+	if err != nil {
+		p = defaultPreferences()
+	}
+
+	return ctx.channel.Format(p.Theme.Colour(p.Locale.Translate("Error when looking up retroachievements data"), errorColour))
+}
+
+func (ctx *commandContext) sprintf(format string, args ...any) (string, error) {
+	p, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	return p.Locale.Sprintf(format, args...), nil
+}
+
+type commandHandler func(ctx *commandContext, args *parsedArgs) (string, error)
+
+type command struct {
+	commandSpec
+	Help    string
+	Example string
+	API     bool
+	Handler commandHandler
+}
+
+type commandHelp struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Usage   string   `json:"usage"`
+	Help    string   `json:"help"`
+	Example string   `json:"example,omitempty"`
+	API     bool     `json:"api,omitempty"`
+}
+
+func (c *command) CommandHelp() commandHelp {
+	return commandHelp{
+		Name:    c.Name,
+		Aliases: c.Aliases,
+		Usage:   c.Usage(),
+		Help:    c.Help,
+		Example: c.Example,
+		API:     c.API,
+	}
+}
+
+func (c *command) HelpText(l *Locale, prefix string) string {
+	var sb strings.Builder
+
+	sb.WriteString(l.Sprintf("%s: %s | usage: %s", c.Name, l.Translate(c.Help), c.Usage()))
+
+	if len(c.Aliases) > 0 {
+		sb.WriteString(l.Sprintf(" | aliases: %s", strings.Join(c.Aliases, ", ")))
+	}
+
+	if c.Example != "" {
+		sb.WriteString(l.Sprintf(" | example: %s%s", prefix, c.Example))
+	}
+
+	return sb.String()
+}
+
+func (c *command) DisplayName() string {
+	for _, a := range c.Aliases {
+		if i := strings.Index(c.Name, a); i >= 0 {
+			return fmt.Sprintf("%s[%s]%s", c.Name[:i], a, c.Name[i+len(a):])
+		}
+	}
+
+	if len(c.Aliases) > 0 {
+		return fmt.Sprintf("%s (%s)", c.Name, strings.Join(c.Aliases, ", "))
+	}
+
+	return c.Name
+}
+
+type commandRegistry struct {
+	commands []*command
+}
+
+func newCommandRegistry(commands ...*command) *commandRegistry {
+	r := &commandRegistry{}
+
+	for _, c := range commands {
+		r.Register(c)
+	}
+
+	return r
+}
+
+func (r *commandRegistry) Register(c *command) {
+	r.commands = append(r.commands, c)
+}
+
+func (r *commandRegistry) Find(name string) (*command, bool) {
+	for _, c := range r.commands {
+		if c.Name == name {
+			return c, true
+		}
+
+		for _, a := range c.Aliases {
+			if a == name {
+				return c, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (r *commandRegistry) names() []string {
+	names := []string{}
+	for _, c := range r.commands {
+		names = append(names, c.DisplayName())
+	}
+
+	return names
+}
+
+func (r *commandRegistry) Usage(l *Locale) string {
+	names := r.names()
+
+	if len(names) == 1 {
+		return l.Sprintf("%s must be passed as a command", names[0])
+	}
+
+	return l.Sprintf("one of %s or %s must be passed as a command", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+func (r *commandRegistry) Help(l *Locale) string {
+	return l.Sprintf("%s, commands: %s", l.Translate(moduleHelp), strings.Join(r.names(), ", "))
+}
+
+func (r *commandRegistry) CommandHelp() []commandHelp {
+	help := []commandHelp{}
+	for _, c := range r.commands {
+		help = append(help, c.CommandHelp())
+	}
+
+	return help
+}
+
+func (r *commandRegistry) Dispatch(ctx *commandContext) (string, error) {
+	ctx.channel = defaultChannelConfig()
+
+	if isChannel(ctx.msg.Dest) {
+		stored, err := ctx.store.ChannelSettings(ctx.channelKey())
+		if err != nil {
+			return "", err
+		}
+
+		ctx.channel = parseChannelConfig(stored)
+	}
+
+	p, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := tokenize(ctx.msg.Args)
+	if err != nil {
+		return p.Locale.Sprintf("Error: %s", err), nil
+	}
+
+	name := ""
+	if len(tokens) >= 1 {
+		name, tokens = tokens[0], tokens[1:]
+	}
+
+	c, ok := r.Find(name)
+	if !ok {
+		return r.Usage(p.Locale), nil
+	}
+
+	if !ctx.channel.Enabled(c.Name) {
+		return p.Locale.Sprintf("%s is disabled in %s", c.Name, ctx.msg.Dest), nil
+	}
+
+	args, err := c.Parse(tokens)
+	if err != nil {
+		return p.Locale.Sprintf("Error: %s (usage: %s)", err, c.Usage()), nil
+	}
+
+	out, err := c.Handler(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.channel.Format(out), nil
+}
+
+var (
+	userArg = argSpec{Name: "user", Optional: true}
+
+	countFlag = flagSpec{Name: "count", Short: "n", Value: "N"}
+	sinceFlag = flagSpec{Name: "since", Short: "s", Value: "DURATION"}
+)
+
+type formatFunc func(*req.Client, string, Preferences) (string, error)
+
+func userCommand(f formatFunc) commandHandler {
+	return func(ctx *commandContext, args *parsedArgs) (string, error) {
+		p, err := ctx.preferences()
+		if err != nil {
+			return "", err
+		}
+
+		return CommandHandler(ctx, args.Arg("user"), func(client *req.Client, user string) (string, error) {
+			return f(client, user, p)
+		})
+	}
+}
+
+func defaultCommands() *commandRegistry {
+	return newCommandRegistry(
+		&command{
+			commandSpec: commandSpec{Name: "set", Aliases: []string{"s"}, Args: []argSpec{{Name: "user"}}},
+			Help:        "link your nick to a retroachievements user",
+			Example:     "s sharktamer",
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				return setUserHandler(ctx, args.Arg("user"))
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "unset", Aliases: []string{"u"}},
+			Help:        "remove the link between your nick and a retroachievements user",
+			Example:     "unset",
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				return unsetUserHandler(ctx)
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "verify", Aliases: []string{"v"}},
+			Help:        "prove you own your linked retroachievements user by adding a token to your profile motto",
+			Example:     "verify",
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				return verifyHandler(ctx)
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "whoami"},
+			Help:        "show which retroachievements user your nick is linked to",
+			Example:     "whoami",
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				return whoisHandler(ctx, ctx.msg.Nick)
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "whois", Args: []argSpec{{Name: "nick"}}},
+			Help:        "show which retroachievements user a nick is linked to",
+			Example:     "whois tester",
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				return whoisHandler(ctx, args.Arg("nick"))
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "achievement", Aliases: []string{"a"}, Args: []argSpec{userArg}, Flags: []flagSpec{countFlag, sinceFlag}},
+			Help:        "show a user's newest achievements",
+			Example:     "a sharktamer -n 3 --since 7d",
+			API:         true,
+			Handler:     achievementHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "last", Aliases: []string{"l"}, Args: []argSpec{userArg}, Flags: []flagSpec{countFlag}},
+			Help:        "show a user's last played games",
+			Example:     "l sharktamer -n 5",
+			API:         true,
+			Handler:     lastGamesHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "current", Aliases: []string{"c"}, Args: []argSpec{userArg}},
+			Help:        "show whether a user is online and what they are playing",
+			Example:     "c sharktamer",
+			API:         true,
+			Handler:     userCommand(raCurrentStatus),
+		},
+		&command{
+			commandSpec: commandSpec{Name: "points", Aliases: []string{"p"}, Args: []argSpec{userArg}},
+			Help:        "show a user's points and rank",
+			Example:     "p sharktamer",
+			API:         true,
+			Handler:     userCommand(raPoints),
+		},
+		&command{
+			commandSpec: commandSpec{Name: "awards", Aliases: []string{"w"}, Args: []argSpec{userArg}},
+			Help:        "show a user's beaten, completed and mastered counts",
+			Example:     "w sharktamer",
+			API:         true,
+			Handler:     userCommand(raAwards),
+		},
+		&command{
+			commandSpec: commandSpec{Name: "game", Aliases: []string{"g"}, Args: []argSpec{userArg}, Flags: []flagSpec{sinceFlag}},
+			Help:        "show a user's progress in their most recently played game",
+			Example:     "g sharktamer",
+			API:         true,
+			Handler:     gameProgressHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "random", Aliases: []string{"r"}, Args: []argSpec{{Name: "console", Optional: true}, {Name: "max-achievements", Optional: true}}},
+			Help:        "recommend a random game you have not played yet",
+			Example:     "r snes 50",
+			API:         true,
+			Handler:     randomHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "consoles", Aliases: []string{"cl"}, Args: []argSpec{{Name: "console", Optional: true}}},
+			Help:        "list consoles, or look one up by name, ID or alias",
+			Example:     "cl gba",
+			API:         true,
+			Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+				p, err := ctx.preferences()
+				if err != nil {
+					return "", err
+				}
+
+				return raConsoleInfo(ctx.client, args.Arg("console"), p)
+			},
+		},
+		&command{
+			commandSpec: commandSpec{Name: "consolestats", Aliases: []string{"cs"}, Args: []argSpec{userArg}},
+			Help:        "show a user's points, achievements and awards by console",
+			Example:     "cs sharktamer",
+			API:         true,
+			Handler:     userCommand(raConsoleStats),
+		},
+		&command{
+			commandSpec: commandSpec{Name: "between", Aliases: []string{"b"}, Args: []argSpec{userArg, {Name: "from"}, {Name: "to"}}},
+			Help:        "summarise achievements earned between two dates (YYYY-MM-DD)",
+			Example:     "b sharktamer 2024-08-01 2024-08-07",
+			API:         true,
+			Handler:     betweenHandler,
+		},
+		namedPeriodCommand("today", "summarise achievements earned today"),
+		namedPeriodCommand("yesterday", "summarise achievements earned yesterday"),
+		namedPeriodCommand("week", "summarise achievements earned this week"),
+		&command{
+			commandSpec: commandSpec{Name: "pref", Args: []argSpec{{Name: "key", Optional: true}, {Name: "value", Optional: true}}},
+			Help:        "show or change your output preferences, use default as the value to reset one",
+			Example:     "pref detail short",
+			Handler:     prefHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "chanset", Args: []argSpec{{Name: "key", Optional: true}, {Name: "value", Optional: true}}},
+			Help:        "show or change this channel's settings, changes are limited to channel operators",
+			Example:     "chanset colours off",
+			Handler:     chansetHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "admin", Args: []argSpec{{Name: "action"}, {Name: "nick", Optional: true}}},
+			Help:        "run an admin action: unlink <nick>, resync <nick>, pause or resume announcements once available, clearcache or status",
+			Example:     "admin unlink tester",
+			Handler:     adminHandler,
+		},
+		&command{
+			commandSpec: commandSpec{Name: "help", Aliases: []string{"h"}, Args: []argSpec{{Name: "command", Optional: true}}},
+			Help:        "show help for a command",
+			Example:     "help achievement",
+			Handler:     helpHandler,
+		},
+	)
+}
+
+func helpHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	p, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	name := args.Arg("command")
+
+	if name == "" {
+		return p.Locale.Sprintf("%s, use help <command> for details", ctx.commands.Help(p.Locale)), nil
+	}
+
+	c, ok := ctx.commands.Find(name)
+	if !ok {
+		return p.Locale.Sprintf("Unknown command %s, %s", name, ctx.commands.Usage(p.Locale)), nil
+	}
+
+	prefix := ""
+	if ctx.msg.Command != "" {
+		prefix = fmt.Sprintf(".%s ", ctx.msg.Command)
+	}
+
+	return c.HelpText(p.Locale, prefix), nil
+}
+
+func randomHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	maxAchievements := 0
+
+	if maxArg := args.Arg("max-achievements"); maxArg != "" {
+		n, err := strconv.Atoi(maxArg)
+		if err != nil || n < 1 {
+			return ctx.sprintf("Error: max achievements must be a positive number")
+		}
+		maxAchievements = n
+	}
+
+	rec, err := ctx.store.GetUser(ctx.nickKey(ctx.msg.Nick))
+	if err != nil {
+		return "", err
+	}
+
+	p, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	console := args.Arg("console")
+
+	if console == "" && len(p.Consoles) > 0 {
+		console = p.Consoles[randIntn(len(p.Consoles))]
+	}
+
+	return raRandomGame(ctx.client, rec.User, console, maxAchievements, p)
+}
+
+func periodHandler(ctx *commandContext, user string, p Period, prefs Preferences) (string, error) {
+	return CommandHandler(ctx, user, func(client *req.Client, user string) (string, error) {
+		return raPeriod(client, user, p, prefs)
+	})
+}
+
+func namedPeriodCommand(name, help string) *command {
+	return &command{
+		commandSpec: commandSpec{Name: name, Args: []argSpec{userArg}},
+		Help:        help,
+		Example:     name,
+		API:         true,
+		Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+			prefs, err := ctx.preferences()
+			if err != nil {
+				return "", err
+			}
+
+			p, _ := namedPeriod(name, prefs.Timezone)
+			return periodHandler(ctx, args.Arg("user"), p, prefs)
+		},
+	}
+}
+
+func betweenHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	prefs, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	p, err := parsePeriod(args.Arg("from"), args.Arg("to"), prefs.Timezone)
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	return periodHandler(ctx, args.Arg("user"), p, prefs)
+}
+
+func achievementHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	count, err := parseCount(args.Flag("count"), defaultRecentAchievements, maxRecentAchievements)
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	since, err := parseSince(args.Flag("since"), defaultSince)
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	return userCommand(func(client *req.Client, user string, p Preferences) (string, error) {
+		return raNewestAchievement(client, user, count, since, p)
+	})(ctx, args)
+}
+
+func lastGamesHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	count, err := parseCount(args.Flag("count"), defaultRecentGames, maxRecentGames)
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	return userCommand(func(client *req.Client, user string, p Preferences) (string, error) {
+		return raLastGames(client, user, count, p)
+	})(ctx, args)
+}
+
+func gameProgressHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	since, err := parseSince(args.Flag("since"), defaultSince)
+	if err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	return userCommand(func(client *req.Client, user string, p Preferences) (string, error) {
+		return raGameProgress(client, user, since, p)
+	})(ctx, args)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandDisplayName(t *testing.T) {
+	cases := map[string]struct {
+		in       commandSpec
+		expected string
+	}{
+		"prefix alias": {
+			in:       commandSpec{Name: "set", Aliases: []string{"s"}},
+			expected: "[s]et",
+		},
+		"inner alias": {
+			in:       commandSpec{Name: "awards", Aliases: []string{"w"}},
+			expected: "a[w]ards",
+		},
+		"unrelated alias": {
+			in:       commandSpec{Name: "consoles", Aliases: []string{"cl"}},
+			expected: "consoles (cl)",
+		},
+		"no alias": {
+			in:       commandSpec{Name: "today"},
+			expected: "today",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &command{commandSpec: tc.in}
+
+			assert.Equal(t, tc.expected, c.DisplayName())
+		})
+	}
+}
+
+func TestCommandRegistryUsage(t *testing.T) {
+	r := defaultCommands()
+
+	assert.Equal(t, "one of [s]et, [u]nset, [v]erify, whoami, whois, [a]chievement, [l]ast, [c]urrent, [p]oints, a[w]ards, [g]ame, [r]andom, consoles (cl), consolestats (cs), [b]etween, today, yesterday, week, pref, chanset, admin or [h]elp must be passed as a command", r.Usage(nil))
+}
+
+func TestCommandRegistryDispatch(t *testing.T) {
+	echo := func(ctx *commandContext, args *parsedArgs) (string, error) {
+		return ctx.msg.Nick + " " + args.Arg("user") + " " + args.Flag("count"), nil
+	}
+
+	r := newCommandRegistry(
+		&command{
+			commandSpec: commandSpec{Name: "echo", Aliases: []string{"e"}, Args: []argSpec{{Name: "user"}}, Flags: []flagSpec{countFlag}},
+			Handler:     echo,
+		},
+	)
+
+	cases := map[string]struct {
+		args     string
+		expected string
+	}{
+		"name": {
+			args:     "echo user",
+			expected: "nick user ",
+		},
+		"alias with flag": {
+			args:     `e "some user" -n 2`,
+			expected: "nick some user 2",
+		},
+		"unknown command": {
+			args:     "nope",
+			expected: "[e]cho must be passed as a command",
+		},
+		"no command": {
+			args:     "",
+			expected: "[e]cho must be passed as a command",
+		},
+		"parse error": {
+			args:     "echo",
+			expected: "Error: missing user (usage: echo <user> [-n|--count N])",
+		},
+		"tokenize error": {
+			args:     `echo "user`,
+			expected: "Error: unterminated quote",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := r.Dispatch(&commandContext{
+				msg:      &gowon.Message{Nick: "nick", Args: tc.args},
+				store:    newMemoryStore(),
+				commands: r,
+			})
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestCommandErrorReply(t *testing.T) {
+	fail := func(ctx *commandContext, args *parsedArgs) (string, error) {
+		return "", errors.New("lookup failed")
+	}
+
+	r := newCommandRegistry(&command{commandSpec: commandSpec{Name: "fail"}, Handler: fail})
+
+	cases := map[string]struct {
+		nick     string
+		dest     string
+		expected string
+	}{
+		"default theme": {
+			nick:     "nick",
+			expected: "{red}Error when looking up retroachievements data{clear}",
+		},
+		"monochrome theme": {
+			nick:     "mono",
+			expected: "Error when looking up retroachievements data",
+		},
+		"colours off": {
+			nick:     "nick",
+			dest:     "#ra",
+			expected: "Error when looking up retroachievements data",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			store := newMemoryStore()
+			assert.Nil(t, store.SetPreference("mono", prefTheme, monochromeThemeName))
+			assert.Nil(t, store.SetChannelSetting("#ra", chanColours, settingOff))
+
+			ctx := testContext(nil, store, tc.nick)
+			ctx.msg.Args = "fail"
+			ctx.msg.Dest = tc.dest
+			ctx.commands = r
+
+			_, err := r.Dispatch(ctx)
+			assert.NotNil(t, err)
+			assert.Equal(t, tc.expected, ctx.errorReply())
+		})
+	}
+}
+
+func TestCommandHelpText(t *testing.T) {
+	c := &command{
+		commandSpec: commandSpec{Name: "last", Aliases: []string{"l"}, Args: []argSpec{userArg}, Flags: []flagSpec{countFlag}},
+		Help:        "show a user's last played games",
+		Example:     "l sharktamer -n 5",
+	}
+
+	assert.Equal(t, "last: show a user's last played games | usage: last [user] [-n|--count N] | aliases: l | example: .ra l sharktamer -n 5", c.HelpText(nil, ".ra "))
+}
+
+func TestHelpHandler(t *testing.T) {
+	r := defaultCommands()
+
+	store := newMemoryStore()
+	assert.Nil(t, store.SetPreference("german", prefLanguage, "de"))
+
+	cases := map[string]struct {
+		nick     string
+		args     string
+		expected string
+	}{
+		"no command": {
+			nick:     "nick",
+			args:     "help",
+			expected: "get players last achievements from retroachievements, commands: [s]et, [u]nset, [v]erify, whoami, whois, [a]chievement, [l]ast, [c]urrent, [p]oints, a[w]ards, [g]ame, [r]andom, consoles (cl), consolestats (cs), [b]etween, today, yesterday, week, pref, chanset, admin, [h]elp, use help <command> for details",
+		},
+		"command alias": {
+			nick:     "nick",
+			args:     "h w",
+			expected: "awards: show a user's beaten, completed and mastered counts | usage: awards [user] | aliases: w | example: .ra w sharktamer",
+		},
+		"translated": {
+			nick:     "german",
+			args:     "h w",
+			expected: "awards: zeigt, wie viele Spiele ein Benutzer geschafft, abgeschlossen und gemeistert hat | Verwendung: awards [user] | Aliase: w | Beispiel: .ra w sharktamer",
+		},
+		"unknown command": {
+			nick:     "nick",
+			args:     "help nope",
+			expected: "Unknown command nope, " + r.Usage(nil),
+		},
+		"translated parse error": {
+			nick:     "german",
+			args:     "between",
+			expected: "Fehler: from fehlt (Verwendung: between [user] <from> <to>)",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := r.Dispatch(&commandContext{
+				msg:      &gowon.Message{Nick: tc.nick, Command: "ra", Args: tc.args},
+				store:    store,
+				commands: r,
+			})
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestCommandRegistryCommandHelp(t *testing.T) {
+	help := defaultCommands().CommandHelp()
+
+	assert.Equal(t, commandHelp{
+		Name:    "between",
+		Aliases: []string{"b"},
+		Usage:   "between [user] <from> <to>",
+		Help:    "summarise achievements earned between two dates (YYYY-MM-DD)",
+		Example: "b sharktamer 2024-08-01 2024-08-07",
+		API:     true,
+	}, help[14])
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+type Console struct {
+	ID           int    `json:"ID"`
+	Name         string `json:"Name"`
+	Active       bool   `json:"Active"`
+	IsGameSystem bool   `json:"IsGameSystem"`
+}
+
+const (
+	maxConsoleStats       = 5
+	userProgressBatchSize = 100
+)
+
+var consoleCache = newCache[string, []Console](24 * time.Hour)
+
+var consoleAliases = map[string]int{
+	"md":        1,
+	"genesis":   1,
+	"megadrive": 1,
+	"n64":       2,
+	"snes":      3,
+	"sfc":       3,
+	"gb":        4,
+	"gba":       5,
+	"gbc":       6,
+	"nes":       7,
+	"famicom":   7,
+	"pce":       8,
+	"tg16":      8,
+	"segacd":    9,
+	"32x":       10,
+	"sms":       11,
+	"psx":       12,
+	"ps1":       12,
+	"lynx":      13,
+	"ngp":       14,
+	"gg":        15,
+	"gc":        16,
+	"ngc":       16,
+	"jaguar":    17,
+	"nds":       18,
+	"ds":        18,
+	"ps2":       21,
+	"2600":      25,
+	"arcade":    27,
+	"vb":        28,
+	"msx":       29,
+	"saturn":    39,
+	"dc":        40,
+	"psp":       41,
+	"3do":       43,
+	"7800":      51,
+	"ws":        53,
+}
+
+func consoleAliasesFor(id int) []string {
+	aliases := []string{}
+
+	for a, i := range consoleAliases {
+		if i == id {
+			aliases = append(aliases, a)
+		}
+	}
+
+	sort.Strings(aliases)
+
+	return aliases
+}
+
+func raConsoles(client *req.Client) ([]Console, error) {
+	if c, ok := consoleCache.Get("consoles"); ok {
+		return c, nil
+	}
+
+	var j []Console
+
+	_, err := client.R().
+		SetQueryParam("a", "1").
+		SetQueryParam("g", "1").
+		SetSuccessResult(&j).
+		Get(raConsoleIDsURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	consoleCache.Set("consoles", j)
+
+	return j, nil
+}
+
+func findConsole(consoles []Console, name string) (Console, bool) {
+	id, err := strconv.Atoi(name)
+
+	if aliasID, ok := consoleAliases[strings.ToLower(name)]; ok {
+		id, err = aliasID, nil
+	}
+
+	for _, c := range consoles {
+		if err == nil && c.ID == id {
+			return c, true
+		}
+
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+
+	return Console{}, false
+}
+
+func raConsoleInfo(client *req.Client, name string, p Preferences) (string, error) {
+	consoles, err := raConsoles(client)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		return raConsoleList(consoles, p)
+	}
+
+	c, ok := findConsole(consoles, name)
+	if !ok {
+		return p.Locale.Sprintf("Console %s not found", name), nil
+	}
+
+	return renderTemplate("console", p, consoleEntry{c, consoleAliasesFor(c.ID)})
+}
+
+type consoleEntry struct {
+	Console
+	Aliases []string
+}
+
+func raConsoleList(consoles []Console, p Preferences) (string, error) {
+	entries := []consoleEntry{}
+	for _, c := range consoles {
+		entries = append(entries, consoleEntry{c, consoleAliasesFor(c.ID)})
+	}
+
+	return renderTemplate("consoles", p, struct {
+		Consoles []consoleEntry
+	}{entries})
+}
+
+type UserProgress map[string]struct {
+	ScoreAchieved int `json:"ScoreAchieved"`
+}
+
+func raUserProgress(client *req.Client, user string, cp CompletionProgress) (UserProgress, error) {
+	ids := []string{}
+	for _, g := range cp.Results {
+		ids = append(ids, strconv.Itoa(g.GameID))
+	}
+
+	up := UserProgress{}
+
+	for len(ids) > 0 {
+		n := min(len(ids), userProgressBatchSize)
+
+		j := UserProgress{}
+
+		_, err := client.R().
+			SetQueryParam("u", user).
+			SetQueryParam("i", strings.Join(ids[:n], ",")).
+			SetSuccessResult(&j).
+			Get(raUserProgressURL)
+
+		if err != nil {
+			return up, err
+		}
+
+		for id, p := range j {
+			up[id] = p
+		}
+
+		ids = ids[n:]
+	}
+
+	return up, nil
+}
+
+type ConsoleStats struct {
+	Name         string
+	Games        int
+	Achievements int
+	Points       int
+	Beaten       int
+	Completed    int
+	Mastered     int
+}
+
+type awardCount struct {
+	Format string
+	Count  int
+}
+
+func (cs ConsoleStats) Awards(softcore bool) []awardCount {
+	awards := []awardCount{}
+
+	if cs.Beaten > 0 {
+		awards = append(awards, awardCount{"%s beaten", cs.Beaten})
+	}
+
+	if cs.Completed > 0 && softcore {
+		awards = append(awards, awardCount{"%s completed", cs.Completed})
+	}
+
+	if cs.Mastered > 0 {
+		awards = append(awards, awardCount{"%s mastered", cs.Mastered})
+	}
+
+	return awards
+}
+
+func consoleStats(cp CompletionProgress, up UserProgress) []ConsoleStats {
+	byConsole := map[string]*ConsoleStats{}
+
+	for _, g := range cp.Results {
+		cs, ok := byConsole[g.ConsoleName]
+		if !ok {
+			cs = &ConsoleStats{Name: g.ConsoleName}
+			byConsole[g.ConsoleName] = cs
+		}
+
+		cs.Games++
+		cs.Achievements += g.NumAwarded
+		cs.Points += up[strconv.Itoa(g.GameID)].ScoreAchieved
+
+		switch g.HighestAwardKind {
+		case "beaten-softcore", "beaten-hardcore":
+			cs.Beaten++
+		case "completed":
+			cs.Completed++
+		case "mastered":
+			cs.Mastered++
+		}
+	}
+
+	out := []ConsoleStats{}
+	for _, cs := range byConsole {
+		out = append(out, *cs)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Points != out[j].Points {
+			return out[i].Points > out[j].Points
+		}
+		if out[i].Achievements != out[j].Achievements {
+			return out[i].Achievements > out[j].Achievements
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+func raConsoleStats(client *req.Client, user string, p Preferences) (string, error) {
+	cp, err := raCompletionProgress(client, user)
+	if err != nil {
+		return "", err
+	}
+
+	up, err := raUserProgress(client, user, cp)
+	if err != nil {
+		return "", err
+	}
+
+	stats := consoleStats(cp, up)
+
+	if len(stats) == 0 {
+		return p.Locale.Sprintf("No played games found for user %s", user), nil
+	}
+
+	if len(stats) > maxConsoleStats {
+		stats = stats[:maxConsoleStats]
+	}
+
+	return renderTemplate("top_consoles", p, struct {
+		User     string
+		Consoles []ConsoleStats
+	}{user, stats})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConsole(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected int
+		found    bool
+	}{
+		"id": {
+			in:       "5",
+			expected: 5,
+			found:    true,
+		},
+		"name": {
+			in:       "nintendo ds",
+			expected: 18,
+			found:    true,
+		},
+		"alias": {
+			in:       "PSX",
+			expected: 12,
+			found:    true,
+		},
+		"not found": {
+			in:       "toaster",
+			expected: 0,
+			found:    false,
+		},
+	}
+
+	j := openTestFile(t, "API_GetConsoleIDs", "consoles.json")
+	consoles := []Console{}
+	err := json.Unmarshal(j, &consoles)
+	assert.Nil(t, err)
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, found := findConsole(consoles, tc.in)
+
+			assert.Equal(t, tc.expected, c.ID)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestConsoleStats(t *testing.T) {
+	j := openTestFile(t, "API_GetUserCompletionProgress", "progress.json")
+	cp := CompletionProgress{}
+	err := json.Unmarshal(j, &cp)
+	assert.Nil(t, err)
+
+	j = openTestFile(t, "API_GetUserProgress", "progress.json")
+	up := UserProgress{}
+	err = json.Unmarshal(j, &up)
+	assert.Nil(t, err)
+
+	expected := []ConsoleStats{
+		{Name: "Game Boy Advance", Games: 1, Achievements: 52, Points: 419, Completed: 1},
+		{Name: "Nintendo DS", Games: 1, Achievements: 30, Points: 250, Beaten: 1},
+		{Name: "SNES/Super Famicom", Games: 1, Achievements: 20, Points: 105, Beaten: 1},
+	}
+
+	assert.Equal(t, expected, consoleStats(cp, up))
+}
+
+func TestRaConsoleInfo(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected string
+	}{
+		"no console": {
+			in:       "",
+			expected: "Consoles: SNES/Super Famicom (sfc, snes), Game Boy Advance (gba), PlayStation (ps1, psx), Nintendo DS (ds, nds)",
+		},
+		"alias": {
+			in:       "snes",
+			expected: "{magenta}SNES/Super Famicom{clear} | ID: 3 | Aliases: sfc, snes",
+		},
+		"not found": {
+			in:       "toaster",
+			expected: "Console toaster not found",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			consoleCache.Clear()
+			json := openTestFile(t, "API_GetConsoleIDs", "consoles.json")
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", raConsoleIDsURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, json)
+				return resp, nil
+			})
+
+			out, err := raConsoleInfo(client, tc.in, defaultPreferences())
+
+			assert.Equal(t, tc.expected, out)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestRaConsoleStats(t *testing.T) {
+	cases := map[string]struct {
+		jsonfn   string
+		expected string
+		err      error
+	}{
+		"stats": {
+			jsonfn:   "progress.json",
+			expected: "user's top consoles: {green}Game Boy Advance: 419 points, 52 achievements in 1 game (1 completed){clear}, {red}Nintendo DS: 250 points, 30 achievements in 1 game (1 beaten){clear}, {blue}SNES/Super Famicom: 105 points, 20 achievements in 1 game (1 beaten){clear}",
+			err:      nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			json := openTestFile(t, "API_GetUserCompletionProgress", tc.jsonfn)
+			progress := openTestFile(t, "API_GetUserProgress", tc.jsonfn)
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", raCompletionProgressURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, json)
+				return resp, nil
+			})
+			httpmock.RegisterResponder("GET", raUserProgressURL, func(request *http.Request) (*http.Response, error) {
+				assert.Equal(t, "17361,555,12747", request.URL.Query().Get("i"))
+				resp := httpmock.NewBytesResponse(http.StatusOK, progress)
+				return resp, nil
+			})
+
+			out, err := raConsoleStats(client, "user", defaultPreferences())
+
+			assert.Equal(t, tc.expected, out)
+			assert.ErrorIs(t, tc.err, err)
+		})
+	}
+}
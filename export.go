@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+const (
+	importMerge     = "merge"
+	importOverwrite = "overwrite"
+)
+
+type storeDump struct {
+	Users       map[string]userRecord        `json:"users"`
+	Preferences map[string]map[string]string `json:"preferences"`
+	Channels    map[string]map[string]string `json:"channels"`
+	History     map[string][]historyEntry    `json:"history"`
+	Cursors     map[string]string            `json:"cursors"`
+}
+
+func exportStore(store Store) (*storeDump, error) {
+	d := &storeDump{
+		Users:       map[string]userRecord{},
+		Preferences: map[string]map[string]string{},
+		Channels:    map[string]map[string]string{},
+		History:     map[string][]historyEntry{},
+	}
+
+	nicks, err := store.Nicks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nick := range nicks {
+		rec, err := store.GetUser(nick)
+		if err != nil {
+			return nil, err
+		}
+		if rec != (userRecord{}) {
+			d.Users[nick] = rec
+		}
+
+		prefs, err := store.Preferences(nick)
+		if err != nil {
+			return nil, err
+		}
+		if len(prefs) > 0 {
+			d.Preferences[nick] = prefs
+		}
+
+		h, err := store.History(nick)
+		if err != nil {
+			return nil, err
+		}
+		if len(h) > 0 {
+			d.History[nick] = h
+		}
+	}
+
+	channels, err := store.Channels()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range channels {
+		settings, err := store.ChannelSettings(channel)
+		if err != nil {
+			return nil, err
+		}
+		d.Channels[channel] = settings
+	}
+
+	d.Cursors, err = store.Cursors()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func importStore(store Store, d *storeDump, mode string) error {
+	if mode == importOverwrite {
+		if err := store.Clear(); err != nil {
+			return err
+		}
+	}
+
+	for nick, rec := range d.Users {
+		err := store.UpdateUser(nick, func(r *userRecord) {
+			*r = rec
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for nick, prefs := range d.Preferences {
+		for k, v := range prefs {
+			if err := store.SetPreference(nick, k, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	for channel, settings := range d.Channels {
+		for k, v := range settings {
+			if err := store.SetChannelSetting(channel, k, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	for nick, h := range d.History {
+		existing, err := store.History(nick)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range h {
+			if len(existing) > 0 && !e.Time.After(existing[len(existing)-1].Time) {
+				continue
+			}
+
+			if err := store.AddHistory(nick, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	for k, v := range d.Cursors {
+		if err := store.SetCursor(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeDump(w io.Writer, d *storeDump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func readDump(r io.Reader) (*storeDump, error) {
+	d := &storeDump{}
+
+	if err := json.NewDecoder(r).Decode(d); err != nil {
+		return nil, fmt.Errorf("unable to read export: %w", err)
+	}
+
+	return d, nil
+}
+
+type exportCommand struct {
+	opts   *Options
+	Output string `short:"o" long:"output" default:"-" description:"file to write the export to, - for stdout"`
+}
+
+func (c *exportCommand) Execute(args []string) error {
+	store, err := openStore(c.opts)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	d, err := exportStore(store)
+	if err != nil {
+		return err
+	}
+
+	if c.Output == "-" {
+		return writeDump(os.Stdout, d)
+	}
+
+	f, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeDump(f, d)
+}
+
+type importCommand struct {
+	opts  *Options
+	Input string `short:"i" long:"input" default:"-" description:"file to read the export from, - for stdin"`
+	Mode  string `short:"m" long:"mode" default:"merge" choice:"merge" choice:"overwrite" description:"merge into or overwrite the stored data"`
+}
+
+func (c *importCommand) Execute(args []string) error {
+	in := io.Reader(os.Stdin)
+
+	if c.Input != "-" {
+		f, err := os.Open(c.Input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	d, err := readDump(in)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(c.opts)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if c.Mode != importOverwrite || storePath(c.opts) == "" {
+		return importStore(store, d, c.Mode)
+	}
+
+	backup := storePath(c.opts) + ".import.bak"
+
+	if err := store.Backup(backup); err != nil {
+		return fmt.Errorf("unable to back up database before overwriting: %w", err)
+	}
+
+	log.Printf("backed up database to %s\n", backup)
+
+	if err := importStore(store, d, c.Mode); err != nil {
+		return fmt.Errorf("import failed, previous data is in %s: %w", backup, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for name, src := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			linkUser(t, src, "alice", "Alice", 1)
+			assert.Nil(t, src.SetPreference("alice", "count", "3"))
+			assert.Nil(t, src.AddHistory("alice", historyEntry{Time: start, Command: "last", Args: "-n 3"}))
+			assert.Nil(t, src.SetChannelSetting("#ra", "colours", "off"))
+			assert.Nil(t, src.SetCursor("announce", "42"))
+
+			d, err := exportStore(src)
+			assert.Nil(t, err)
+
+			var buf bytes.Buffer
+			assert.Nil(t, writeDump(&buf, d))
+
+			read, err := readDump(&buf)
+			assert.Nil(t, err)
+
+			dst := newMemoryStore()
+			assert.Nil(t, importStore(dst, read, importMerge))
+
+			exported, err := exportStore(dst)
+			assert.Nil(t, err)
+			assert.Equal(t, d, exported)
+		})
+	}
+}
+
+func TestImportStoreModes(t *testing.T) {
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	d := &storeDump{
+		Users: map[string]userRecord{"alice": {User: "AliceNew", ID: 2}},
+		History: map[string][]historyEntry{"alice": {
+			{Time: start, Command: "last"},
+			{Time: start.Add(2 * time.Minute), Command: "points"},
+		}},
+	}
+
+	cases := map[string]struct {
+		mode    string
+		nicks   []string
+		history []string
+	}{
+		"merge": {
+			mode:    importMerge,
+			nicks:   []string{"alice", "bob"},
+			history: []string{"awards", "points"},
+		},
+		"overwrite": {
+			mode:    importOverwrite,
+			nicks:   []string{"alice"},
+			history: []string{"last", "points"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			store := newMemoryStore()
+			linkUser(t, store, "alice", "AliceOld", 1)
+			linkUser(t, store, "bob", "Bob", 3)
+			assert.Nil(t, store.AddHistory("alice", historyEntry{Time: start.Add(time.Minute), Command: "awards"}))
+
+			assert.Nil(t, importStore(store, d, tc.mode))
+
+			nicks, err := store.Nicks()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.nicks, nicks)
+
+			rec, err := store.GetUser("alice")
+			assert.Nil(t, err)
+			assert.Equal(t, userRecord{User: "AliceNew", ID: 2}, rec)
+
+			h, err := store.History("alice")
+			assert.Nil(t, err)
+
+			commands := []string{}
+			for _, e := range h {
+				commands = append(commands, e.Command)
+			}
+			assert.Equal(t, tc.history, commands)
+		})
+	}
+}
+
+func TestReadDumpInvalid(t *testing.T) {
+	_, err := readDump(bytes.NewBufferString("{"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLocaleName = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+type Locale struct {
+	Name      string            `json:"-"`
+	Thousands string            `json:"thousands"`
+	Messages  map[string]string `json:"messages"`
+}
+
+var defaultLocale = &Locale{
+	Name:      defaultLocaleName,
+	Thousands: ",",
+	Messages:  map[string]string{},
+}
+
+var locales = mustLoadLocales()
+
+func mustLoadLocales() map[string]*Locale {
+	loaded := map[string]*Locale{defaultLocaleName: defaultLocale}
+
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, f := range files {
+		b, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		l := &Locale{Name: strings.TrimSuffix(f.Name(), ".json")}
+		if err := json.Unmarshal(b, l); err != nil {
+			panic(fmt.Sprintf("unable to read locale %s: %s", f.Name(), err))
+		}
+
+		loaded[l.Name] = l
+	}
+
+	return loaded
+}
+
+func findLocale(name string) (*Locale, bool) {
+	l, ok := locales[strings.ToLower(name)]
+	return l, ok
+}
+
+func localeSetting(name string) (*Locale, error) {
+	l, ok := findLocale(name)
+	if !ok {
+		return nil, localeErrorf("unknown language %s, available: %s", name, strings.Join(localeNames(), ", "))
+	}
+
+	return l, nil
+}
+
+func localeNames() []string {
+	names := []string{}
+
+	for n := range locales {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (l *Locale) Translate(msg string) string {
+	if l == nil {
+		return msg
+	}
+
+	if t, ok := l.Messages[msg]; ok {
+		return t
+	}
+
+	return msg
+}
+
+func (l *Locale) Sprintf(format string, args ...any) string {
+	translated := make([]any, len(args))
+
+	for i, a := range args {
+		if err, ok := a.(error); ok {
+			a = l.Error(err)
+		}
+		translated[i] = a
+	}
+
+	return fmt.Sprintf(l.Translate(format), translated...)
+}
+
+type localeError struct {
+	format string
+	args   []any
+}
+
+func localeErrorf(format string, args ...any) error {
+	return &localeError{format: format, args: args}
+}
+
+func (e *localeError) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+func (l *Locale) Error(err error) string {
+	var le *localeError
+	if errors.As(err, &le) {
+		return l.Sprintf(le.format, le.args...)
+	}
+
+	return err.Error()
+}
+
+func (l *Locale) Number(n int) string {
+	if l == nil {
+		l = defaultLocale
+	}
+
+	digits := strconv.Itoa(n)
+
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var sb strings.Builder
+
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(l.Thousands)
+		}
+		sb.WriteRune(d)
+	}
+
+	return sign + sb.String()
+}
+
+func (l *Locale) Plural(n int, word string) string {
+	if n == 1 {
+		return l.Sprintf("%s "+word, l.Number(n))
+	}
+
+	return l.Sprintf("%s "+word+"s", l.Number(n))
+}
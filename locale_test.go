@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlural(t *testing.T) {
+	cases := map[string]struct {
+		n        int
+		expected string
+	}{
+		"zero": {
+			n:        0,
+			expected: "0 points",
+		},
+		"one": {
+			n:        1,
+			expected: "1 point",
+		},
+		"many": {
+			n:        12,
+			expected: "12 points",
+		},
+		"grouped": {
+			n:        1200,
+			expected: "1,200 points",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, defaultLocale.Plural(tc.n, "point"))
+		})
+	}
+}
+
+func TestLocaleNumber(t *testing.T) {
+	de, _ := findLocale("de")
+
+	cases := map[string]struct {
+		locale   *Locale
+		n        int
+		expected string
+	}{
+		"small": {
+			locale:   defaultLocale,
+			n:        999,
+			expected: "999",
+		},
+		"thousands": {
+			locale:   defaultLocale,
+			n:        51006,
+			expected: "51,006",
+		},
+		"millions": {
+			locale:   defaultLocale,
+			n:        1234567,
+			expected: "1,234,567",
+		},
+		"negative": {
+			locale:   defaultLocale,
+			n:        -1234,
+			expected: "-1,234",
+		},
+		"german": {
+			locale:   de,
+			n:        1234567,
+			expected: "1.234.567",
+		},
+		"nil locale": {
+			locale:   nil,
+			n:        1000,
+			expected: "1,000",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.locale.Number(tc.n))
+		})
+	}
+}
+
+func TestLocaleSprintf(t *testing.T) {
+	de, _ := findLocale("de")
+
+	assert.Equal(t, "Benutzer user nicht gefunden", de.Sprintf("User %s not found", "user"))
+	assert.Equal(t, "untranslated user", de.Sprintf("untranslated %s", "user"))
+	assert.Equal(t, "User user not found", (*Locale)(nil).Sprintf("User %s not found", "user"))
+}
+
+func TestLocaleError(t *testing.T) {
+	de, _ := findLocale("de")
+
+	err := localeErrorf("missing %s", "user")
+
+	assert.Equal(t, "missing user", err.Error())
+	assert.Equal(t, "Fehler: user fehlt", de.Sprintf("Error: %s", err))
+	assert.Equal(t, "Fehler: plain", de.Sprintf("Error: %s", errors.New("plain")))
+}
+
+func usedMessages(t *testing.T) []string {
+	msgs := []string{moduleHelp}
+
+	for _, c := range defaultCommands().commands {
+		msgs = append(msgs, c.Help)
+	}
+
+	for _, values := range append(settingValues(nil, preferenceSpecs), settingValues(nil, channelSettingSpecs)...) {
+		if _, v, _ := strings.Cut(strings.TrimSuffix(values, ")"), " ("); strings.Contains(v, " ") {
+			msgs = append(msgs, v)
+		}
+	}
+
+	translators := map[string]bool{"sprintf": true, "Sprintf": true, "Translate": true}
+
+	files, err := filepath.Glob("*.go")
+	assert.Nil(t, err)
+
+	for _, fn := range files {
+		if strings.HasSuffix(fn, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), fn, nil, 0)
+		assert.Nil(t, err)
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				if fun.Name != "localeErrorf" {
+					return true
+				}
+			case *ast.SelectorExpr:
+				if x, ok := fun.X.(*ast.Ident); (ok && x.Name == "fmt") || !translators[fun.Sel.Name] {
+					return true
+				}
+			default:
+				return true
+			}
+
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				msg, err := strconv.Unquote(lit.Value)
+				assert.Nil(t, err)
+				msgs = append(msgs, msg)
+			}
+
+			return true
+		})
+	}
+
+	tr := regexp.MustCompile(`tr "([^"]*)"`)
+	plural := regexp.MustCompile(`plural \S+ "([^"]*)"`)
+
+	templates, err := filepath.Glob("templates/*.tmpl")
+	assert.Nil(t, err)
+
+	for _, fn := range templates {
+		b, err := os.ReadFile(fn)
+		assert.Nil(t, err)
+
+		for _, m := range tr.FindAllStringSubmatch(string(b), -1) {
+			msgs = append(msgs, m[1])
+		}
+
+		for _, m := range plural.FindAllStringSubmatch(string(b), -1) {
+			msgs = append(msgs, "%s "+m[1], "%s "+m[1]+"s")
+		}
+	}
+
+	return msgs
+}
+
+func TestLocaleMessagesKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[sd]`)
+
+	for name, l := range locales {
+		for msg, translated := range l.Messages {
+			assert.Equal(t, verbs.FindAllString(msg, -1), verbs.FindAllString(translated, -1), "%s: %s", name, msg)
+		}
+	}
+
+	msgs := usedMessages(t)
+
+	for name, l := range locales {
+		if name == defaultLocaleName {
+			continue
+		}
+
+		for _, msg := range msgs {
+			assert.Contains(t, l.Messages, msg, "%s is missing a translation", name)
+		}
+	}
+}
+
+func TestLocaleSetting(t *testing.T) {
+	l, err := localeSetting("ES")
+	assert.Nil(t, err)
+	assert.Equal(t, "es", l.Name)
+
+	_, err = localeSetting("xx")
+	assert.EqualError(t, err, "unknown language xx, available: de, en, es")
+}
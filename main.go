@@ -4,10 +4,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-
 	"strings"
 
-	"github.com/boltdb/bolt"
 	"github.com/gin-gonic/gin"
 	"github.com/gowon-irc/go-gowon"
 	"github.com/imroc/req/v3"
@@ -15,8 +13,21 @@ import (
 )
 
 type Options struct {
-	APIKey string `short:"k" long:"api-key" env:"GOWON_RA_API_KEY" required:"true" description:"retroachievements api key"`
+	APIKey string `short:"k" long:"api-key" env:"GOWON_RA_API_KEY" description:"retroachievements api key, required when serving"`
 	KVPath string `short:"K" long:"kv-path" env:"GOWON_RA_KV_PATH" default:"kv.db" description:"path to kv db"`
+
+	Store      string `long:"store" env:"GOWON_RA_STORE" default:"bolt" choice:"bolt" choice:"sqlite" choice:"memory" description:"storage backend"`
+	SQLitePath string `long:"sqlite-path" env:"GOWON_RA_SQLITE_PATH" default:"kv.sqlite" description:"path to sqlite db"`
+
+	Casemapping string `long:"casemapping" env:"GOWON_RA_CASEMAPPING" default:"rfc1459" choice:"ascii" choice:"rfc1459" choice:"strict-rfc1459" description:"irc casemapping used to compare nicks"`
+	Network     string `long:"network" env:"GOWON_RA_NETWORK" description:"network name used to namespace stored nicks, overridden by a message's network tag"`
+
+	ThemesPath    string `long:"themes" env:"GOWON_RA_THEMES_PATH" description:"path to a json file of extra output themes"`
+	Renderer      string `long:"renderer" env:"GOWON_RA_RENDERER" default:"gowon" choice:"gowon" choice:"mirc" choice:"plain" choice:"markdown" choice:"ansi" description:"default output markup, overridden by a request's renderer query parameter"`
+	TemplatesPath string `long:"templates" env:"GOWON_RA_TEMPLATES_PATH" description:"path to a directory of .tmpl files overriding the default output templates"`
+
+	Admins  []string `long:"admin" env:"GOWON_RA_ADMINS" env-delim:"," description:"nick or nick!user@host mask allowed to run admin commands"`
+	Chanops []string `long:"chanop" env:"GOWON_RA_CHANOPS" env-delim:"," description:"nick or nick!user@host mask allowed to change channel settings, optionally limited to one channel as #channel:mask"`
 }
 
 const (
@@ -24,143 +35,346 @@ const (
 	moduleHelp = "get players last achievements from retroachievements"
 )
 
-func setUser(kv *bolt.DB, nick, user []byte) error {
-	err := kv.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("retroachievements"))
-		return b.Put([]byte(nick), []byte(user))
-	})
-	return err
+type apiResponse struct {
+	Command  string `json:"command,omitempty"`
+	Template string `json:"template,omitempty"`
+	Data     any    `json:"data,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type moduleHelpResponse struct {
+	gowon.Message
+	Commands []commandHelp `json:"commands"`
+}
+
+func openBackend(opts *Options) (Store, error) {
+	switch opts.Store {
+	case "sqlite":
+		return openSQLiteStore(opts.SQLitePath)
+	case "memory":
+		return newMemoryStore(), nil
+	default:
+		return openBoltStore(opts.KVPath)
+	}
+}
+
+func openStore(opts *Options) (Store, error) {
+	store, err := openBackend(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateNickKeys(store, opts.nickMapper(), storePath(opts)); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (opts *Options) nickMapper() nickMapper {
+	return nickMapper{
+		Casemapping: opts.Casemapping,
+		Network:     opts.Network,
+	}
+}
+
+func storePath(opts *Options) string {
+	switch opts.Store {
+	case "sqlite":
+		return opts.SQLitePath
+	case "memory":
+		return ""
+	default:
+		return opts.KVPath
+	}
 }
 
-func getUser(kv *bolt.DB, nick []byte) (user []byte, err error) {
-	err = kv.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("retroachievements"))
-		v := b.Get([]byte(nick))
-		user = v
-		return nil
+type server struct {
+	client   *req.Client
+	store    Store
+	commands *commandRegistry
+	nicks    nickMapper
+	perms    permissions
+	renderer string
+}
+
+func (s *server) messageHandler(c *gin.Context) {
+	var m gowon.Message
+
+	if err := c.BindJSON(&m); err != nil {
+		log.Println("Error: unable to bind message to json", err)
+		return
+	}
+
+	name := s.renderer
+	if r := c.Query("renderer"); r != "" {
+		name = r
+	}
+
+	renderer, err := findRenderer(name)
+	if err != nil {
+		m.Msg = fmt.Sprintf("Error: %s", err)
+		c.IndentedJSON(http.StatusBadRequest, &m)
+		return
+	}
+
+	status := http.StatusOK
+
+	ctx := &commandContext{
+		client:   s.client,
+		store:    s.store,
+		msg:      &m,
+		commands: s.commands,
+		nicks:    s.nicks,
+		perms:    s.perms,
+	}
+
+	out, err := s.commands.Dispatch(ctx)
+	if err != nil {
+		log.Println(err)
+		out = ctx.errorReply()
+		status = http.StatusInternalServerError
+	}
+
+	m.Msg = renderer.Render(out)
+	c.IndentedJSON(status, &m)
+}
+
+func (s *server) apiHandler(c *gin.Context) {
+	name := c.Param("command")
+
+	cmd, ok := s.commands.Find(name)
+	if !ok || !cmd.API {
+		c.IndentedJSON(http.StatusNotFound, &apiResponse{Error: fmt.Sprintf("unknown command %s", name)})
+		return
+	}
+
+	m := gowon.Message{
+		Module: moduleName,
+		Nick:   c.Query("nick"),
+		Args:   strings.TrimSpace(cmd.Name + " " + c.Query("args")),
+	}
+
+	capture := &capturedOutput{}
+
+	out, err := s.commands.Dispatch(&commandContext{
+		client:   s.client,
+		store:    s.store,
+		msg:      &m,
+		commands: s.commands,
+		nicks:    s.nicks,
+		perms:    s.perms,
+		capture:  capture,
 	})
-	return user, err
+	if err != nil {
+		log.Println(err)
+		c.IndentedJSON(http.StatusInternalServerError, &apiResponse{Command: cmd.Name, Error: "unable to look up retroachievements data"})
+		return
+	}
+
+	if capture.Data == nil {
+		c.IndentedJSON(http.StatusOK, &apiResponse{Command: cmd.Name, Message: renderers[rendererPlain].Render(out)})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, &apiResponse{Command: cmd.Name, Template: capture.Template, Data: capture.Data})
 }
 
-func parseArgs(msg string) (command, user string) {
-	fields := strings.Fields(msg)
+func resolveUser(ctx *commandContext, arg string) (string, error) {
+	nick, explicit := strings.CutPrefix(arg, "@")
 
-	if len(fields) >= 1 {
-		command = fields[0]
+	rec, err := ctx.store.GetUser(ctx.nickKey(nick))
+	if err != nil {
+		return "", err
 	}
 
-	if len(fields) >= 2 {
-		user = fields[1]
+	if rec.User != "" {
+		return rec.User, nil
 	}
 
-	return command, user
+	if explicit {
+		return "", nil
+	}
+
+	return arg, nil
 }
 
-func setUserHandler(kv *bolt.DB, nick, user string) (string, error) {
+func setUserHandler(ctx *commandContext, user string) (string, error) {
 	if user == "" {
-		return "Error: username needed", nil
+		return ctx.sprintf("Error: username needed")
 	}
 
-	err := setUser(kv, []byte(nick), []byte(user))
+	profile, err := raUserProfile(ctx.client, user)
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("set %s's user to %s", nick, user), nil
+	if profile.ID == 0 || profile.User == "" {
+		return ctx.sprintf("Error: retroachievements user %s not found", user)
+	}
+
+	err = ctx.store.UpdateUser(ctx.nickKey(ctx.msg.Nick), func(rec *userRecord) {
+		rec.User = profile.User
+		rec.ID = profile.ID
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.sprintf("set %s's user to %s", ctx.msg.Nick, profile.User)
 }
 
-type commandFunc func(*req.Client, string) (string, error)
+func whoisHandler(ctx *commandContext, nick string) (string, error) {
+	rec, err := ctx.store.GetUser(ctx.nickKey(nick))
+	if err != nil {
+		return "", err
+	}
 
-func CommandHandler(client *req.Client, kv *bolt.DB, nick, user string, f commandFunc) (string, error) {
-	if user != "" {
-		return f(client, user)
+	if rec.User == "" {
+		return ctx.sprintf("%s is not linked to a retroachievements user", nick)
+	}
+
+	if rec.Verified() {
+		return ctx.sprintf("%s is linked to %s (verified)", nick, rec.User)
 	}
 
-	savedUser, err := getUser(kv, []byte(nick))
+	return ctx.sprintf("%s is linked to %s", nick, rec.User)
+}
+
+func unsetUserHandler(ctx *commandContext) (string, error) {
+	nick := ctx.msg.Nick
+	key := ctx.nickKey(nick)
+
+	rec, err := ctx.store.GetUser(key)
 	if err != nil {
 		return "", err
 	}
 
-	if len(savedUser) == 0 {
-		return "Error: username needed", nil
+	if rec.User == "" {
+		return ctx.sprintf("%s is not linked to a retroachievements user", nick)
+	}
+
+	err = ctx.store.DeleteUser(key)
+	if err != nil {
+		return "", err
 	}
 
-	return f(client, string(savedUser))
+	return ctx.sprintf("unset %s's user %s", nick, rec.User)
 }
 
-func raHandler(client *req.Client, kv *bolt.DB, m *gowon.Message) (string, error) {
+type commandFunc func(*req.Client, string) (string, error)
 
-	command, user := parseArgs(m.Args)
+func CommandHandler(ctx *commandContext, user string, f commandFunc) (string, error) {
+	if user != "" {
+		resolved, err := resolveUser(ctx, user)
+		if err != nil {
+			return "", err
+		}
+
+		if resolved == "" {
+			return ctx.sprintf("Error: %s is not linked to a retroachievements user", strings.TrimPrefix(user, "@"))
+		}
 
-	switch command {
-	case "s", "set":
-		return setUserHandler(kv, m.Nick, user)
-	case "a", "achievement":
-		return CommandHandler(client, kv, m.Nick, user, raNewestAchievement)
-	case "l", "last":
-		return CommandHandler(client, kv, m.Nick, user, raLastGames)
-	case "c", "current":
-		return CommandHandler(client, kv, m.Nick, user, raCurrentStatus)
-	case "p", "points":
-		return CommandHandler(client, kv, m.Nick, user, raPoints)
-	case "w", "awards":
-		return CommandHandler(client, kv, m.Nick, user, raAwards)
-	case "g", "game":
-		return CommandHandler(client, kv, m.Nick, user, raGameProgress)
+		return f(ctx.client, resolved)
 	}
 
-	return "one of [s]et, [a]chievement, [l]ast, [c]urrent, [p]oints, a[w]ards or [g]ame must be passed as a command", nil
+	rec, err := ctx.store.GetUser(ctx.nickKey(ctx.msg.Nick))
+	if err != nil {
+		return "", err
+	}
+
+	if rec.User == "" {
+		return ctx.sprintf("Error: username needed")
+	}
+
+	return f(ctx.client, rec.User)
 }
 
 func main() {
-	log.Printf("%s starting\n", moduleName)
-
 	opts := Options{}
-	if _, err := flags.Parse(&opts); err != nil {
+
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.SubcommandsOptional = true
+
+	_, err := parser.AddCommand("export", "export stored data", "write all stored user links, preferences and history as json", &exportCommand{opts: &opts})
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	kv, err := bolt.Open(opts.KVPath, 0666, nil)
+	_, err = parser.AddCommand("import", "import stored data", "read json written by export, merging into or overwriting the stored data", &importCommand{opts: &opts})
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer kv.Close()
 
-	err = kv.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("retroachievements"))
-		return err
-	})
-	if err != nil {
+	if _, err := parser.Parse(); err != nil {
+		if flags.WroteHelp(err) {
+			return
+		}
 		log.Fatal(err)
 	}
 
-	httpClient := req.C().
-		SetCommonQueryParam("y", opts.APIKey)
+	if parser.Active != nil {
+		return
+	}
 
-	r := gin.Default()
-	r.POST("/message", func(c *gin.Context) {
-		var m gowon.Message
+	if opts.APIKey == "" {
+		log.Fatal("the required flag `-k, --api-key' was not specified")
+	}
 
-		if err := c.BindJSON(&m); err != nil {
-			log.Println("Error: unable to bind message to json", err)
-			return
+	log.Printf("%s starting\n", moduleName)
+
+	if opts.ThemesPath != "" {
+		if err := loadThemes(opts.ThemesPath); err != nil {
+			log.Fatal(err)
 		}
+	}
 
-		out, err := raHandler(httpClient, kv, &m)
-		if err != nil {
-			log.Println(err)
-			m.Msg = "{red}Error when looking up retroachievements data{clear}"
-			c.IndentedJSON(http.StatusInternalServerError, &m)
+	if opts.TemplatesPath != "" {
+		if err := loadTemplates(opts.TemplatesPath); err != nil {
+			log.Fatal(err)
 		}
+	}
 
-		m.Msg = out
-		c.IndentedJSON(http.StatusOK, &m)
-	})
+	store, err := openStore(&opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	nicks := opts.nickMapper()
+
+	perms := permissions{
+		Admins:  opts.Admins,
+		Chanops: opts.Chanops,
+	}
+
+	httpClient := req.C().
+		SetCommonQueryParam("y", opts.APIKey)
+
+	commands := defaultCommands()
+
+	srv := &server{
+		client:   httpClient,
+		store:    store,
+		commands: commands,
+		nicks:    nicks,
+		perms:    perms,
+		renderer: opts.Renderer,
+	}
+
+	r := gin.Default()
+	r.POST("/message", srv.messageHandler)
+	r.GET("/api/v1/:command", srv.apiHandler)
 
 	r.GET("/help", func(c *gin.Context) {
-		c.IndentedJSON(http.StatusOK, &gowon.Message{
-			Module: moduleName,
-			Msg:    moduleHelp,
+		c.IndentedJSON(http.StatusOK, &moduleHelpResponse{
+			Message: gowon.Message{
+				Module: moduleName,
+				Msg:    commands.Help(defaultLocale),
+			},
+			Commands: commands.CommandHelp(),
 		})
 	})
 
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func linkUser(t *testing.T, store Store, nick, user string, id int) {
+	err := store.UpdateUser(nick, func(rec *userRecord) {
+		rec.User = user
+		rec.ID = id
+	})
+	if err != nil {
+		t.Fatalf("failed to link test user: %s", err)
+	}
+}
+
+func testContext(client *req.Client, store Store, nick string) *commandContext {
+	return &commandContext{
+		client: client,
+		store:  store,
+		msg:    &gowon.Message{Nick: nick},
+		nicks:  nickMapper{Casemapping: casemappingRFC1459},
+	}
+}
+
+func TestWhoisHandler(t *testing.T) {
+	store := newMemoryStore()
+	linkUser(t, store, "nick", "user", 0)
+
+	cases := map[string]struct {
+		nick     string
+		expected string
+	}{
+		"linked": {
+			nick:     "nick",
+			expected: "nick is linked to user",
+		},
+		"not linked": {
+			nick:     "other",
+			expected: "other is not linked to a retroachievements user",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := whoisHandler(testContext(nil, store, "nick"), tc.nick)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestUnsetUserHandler(t *testing.T) {
+	store := newMemoryStore()
+	linkUser(t, store, "nick", "user", 0)
+
+	ctx := testContext(nil, store, "Nick")
+
+	out, err := unsetUserHandler(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "unset Nick's user user", out)
+
+	rec, err := store.GetUser("nick")
+	assert.Nil(t, err)
+	assert.Empty(t, rec.User)
+
+	out, err = unsetUserHandler(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "Nick is not linked to a retroachievements user", out)
+}
+
+func TestSetUserHandler(t *testing.T) {
+	cases := map[string]struct {
+		jsonfn   string
+		status   int
+		user     string
+		expected string
+		stored   string
+		id       int
+	}{
+		"canonical name stored": {
+			jsonfn:   "profile.json",
+			status:   http.StatusOK,
+			user:     "sharktamer",
+			expected: "set nick's user to Sharktamer",
+			stored:   "Sharktamer",
+			id:       119117,
+		},
+		"not found": {
+			jsonfn:   "not_found.json",
+			status:   http.StatusNotFound,
+			user:     "nobody",
+			expected: "Error: retroachievements user nobody not found",
+			stored:   "",
+			id:       0,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			store := newMemoryStore()
+			json := openTestFile(t, "API_GetUserProfile", tc.jsonfn)
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", raUserProfileURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(tc.status, json)
+				return resp, nil
+			})
+
+			out, err := setUserHandler(testContext(client, store, "nick"), tc.user)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+
+			rec, err := store.GetUser("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.stored, rec.User)
+			assert.Equal(t, tc.id, rec.ID)
+		})
+	}
+}
+
+func TestCommandHandler(t *testing.T) {
+	store := newMemoryStore()
+	linkUser(t, store, "nick", "NickUser", 0)
+	linkUser(t, store, "other", "OtherUser", 0)
+	assert.Nil(t, store.SetPreference("german", prefLanguage, "de"))
+
+	echo := func(client *req.Client, user string) (string, error) {
+		return user, nil
+	}
+
+	cases := map[string]struct {
+		nick     string
+		user     string
+		expected string
+	}{
+		"saved user": {
+			nick:     "Nick",
+			user:     "",
+			expected: "NickUser",
+		},
+		"no saved user": {
+			nick:     "nobody",
+			user:     "",
+			expected: "Error: username needed",
+		},
+		"no saved user translated": {
+			nick:     "german",
+			user:     "",
+			expected: "Fehler: Benutzername benötigt",
+		},
+		"registered nick": {
+			nick:     "Nick",
+			user:     "OTHER",
+			expected: "OtherUser",
+		},
+		"ra username": {
+			nick:     "Nick",
+			user:     "someone",
+			expected: "someone",
+		},
+		"explicit nick": {
+			nick:     "Nick",
+			user:     "@nick",
+			expected: "NickUser",
+		},
+		"explicit unknown nick": {
+			nick:     "Nick",
+			user:     "@someone",
+			expected: "Error: someone is not linked to a retroachievements user",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := CommandHandler(testContext(nil, store, tc.nick), tc.user, echo)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
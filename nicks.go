@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gowon-irc/go-gowon"
+)
+
+const (
+	casemappingASCII         = "ascii"
+	casemappingRFC1459       = "rfc1459"
+	casemappingStrictRFC1459 = "strict-rfc1459"
+
+	networkTag = "network"
+)
+
+func foldNick(casemapping, nick string) string {
+	var sb strings.Builder
+
+	for _, r := range nick {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			r += 'a' - 'A'
+		case casemapping == casemappingASCII:
+		case r == '[':
+			r = '{'
+		case r == ']':
+			r = '}'
+		case r == '\\':
+			r = '|'
+		case r == '~' && casemapping == casemappingRFC1459:
+			r = '^'
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+type nickMapper struct {
+	Casemapping string
+	Network     string
+}
+
+func (nm nickMapper) MessageNetwork(m *gowon.Message) string {
+	if m != nil && m.Tags[networkTag] != "" {
+		return m.Tags[networkTag]
+	}
+
+	return nm.Network
+}
+
+func (nm nickMapper) Key(network, nick string) []byte {
+	key := foldNick(nm.Casemapping, nick)
+
+	if network != "" {
+		key = strings.ToLower(network) + "/" + key
+	}
+
+	return []byte(key)
+}
+
+func (nm nickMapper) normaliseKey(key string) []byte {
+	if network, nick, ok := strings.Cut(key, "/"); ok {
+		return nm.Key(network, nick)
+	}
+
+	return nm.Key(nm.Network, key)
+}
+
+func (nm nickMapper) needsNormalising(keys []string) bool {
+	for _, k := range keys {
+		if string(nm.normaliseKey(k)) != k {
+			return true
+		}
+	}
+
+	return false
+}
+
+func migrateNickKeys(store Store, nm nickMapper, backupPath string) error {
+	nicks, err := store.Nicks()
+	if err != nil {
+		return err
+	}
+
+	channels, err := store.Channels()
+	if err != nil {
+		return err
+	}
+
+	if !nm.needsNormalising(nicks) && !nm.needsNormalising(channels) {
+		return nil
+	}
+
+	if backupPath != "" {
+		backup := backupPath + ".nicks.bak"
+
+		if err := store.Backup(backup); err != nil {
+			return fmt.Errorf("unable to back up database before rewriting nick keys: %w", err)
+		}
+
+		log.Printf("backed up database to %s\n", backup)
+	}
+
+	if err := normaliseNickKeys(store, nm); err != nil {
+		return fmt.Errorf("rewriting nick keys failed: %w", err)
+	}
+
+	if err := normaliseChannelKeys(store, nm); err != nil {
+		return fmt.Errorf("rewriting channel keys failed: %w", err)
+	}
+
+	log.Printf("rewrote nick and channel keys for casemapping %s, network %q\n", nm.Casemapping, nm.Network)
+
+	return nil
+}
+
+func normaliseChannelKeys(store Store, nm nickMapper) error {
+	channels, err := store.Channels()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range channels {
+		key := string(nm.normaliseKey(k))
+		if key == k {
+			continue
+		}
+
+		settings, err := store.ChannelSettings(k)
+		if err != nil {
+			return err
+		}
+
+		existing, err := store.ChannelSettings(key)
+		if err != nil {
+			return err
+		}
+
+		for name, v := range settings {
+			if current, ok := existing[name]; ok {
+				if current != v {
+					log.Printf("merging %s into %s: kept %s=%s, dropped %s=%s\n", k, key, name, current, name, v)
+				}
+			} else if err := store.SetChannelSetting(key, name, v); err != nil {
+				return err
+			}
+
+			if err := store.DeleteChannelSetting(k, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func normaliseNickKeys(store Store, nm nickMapper) error {
+	nicks, err := store.Nicks()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range nicks {
+		key := string(nm.normaliseKey(k))
+		if key == k {
+			continue
+		}
+
+		if err := mergeNickKey(store, k, key); err != nil {
+			return err
+		}
+
+		if err := store.RenameNick(k, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeNickKey(store Store, from, to string) error {
+	fromRec, err := store.GetUser(from)
+	if err != nil {
+		return err
+	}
+
+	toRec, err := store.GetUser(to)
+	if err != nil {
+		return err
+	}
+
+	if fromRec.User != "" && toRec.User != "" && fromRec != toRec {
+		keep, drop := toRec, fromRec
+		if fromRec.Verified() && !toRec.Verified() {
+			keep, drop = fromRec, toRec
+		}
+
+		err := store.UpdateUser(to, func(r *userRecord) {
+			*r = keep
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("merging %s into %s: kept link to %s, dropped link to %s\n", from, to, keep.User, drop.User)
+	}
+
+	fromPrefs, err := store.Preferences(from)
+	if err != nil {
+		return err
+	}
+
+	toPrefs, err := store.Preferences(to)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range fromPrefs {
+		if existing, ok := toPrefs[k]; ok {
+			if existing != v {
+				log.Printf("merging %s into %s: kept %s=%s, dropped %s=%s\n", from, to, k, existing, k, v)
+			}
+			continue
+		}
+
+		if err := store.SetPreference(to, k, v); err != nil {
+			return err
+		}
+	}
+
+	fromHistory, err := store.History(from)
+	if err != nil {
+		return err
+	}
+
+	toHistory, err := store.History(to)
+	if err != nil {
+		return err
+	}
+
+	if len(fromHistory) > 0 && len(toHistory) > 0 {
+		log.Printf("merging %s into %s: dropped %d history entries\n", from, to, len(fromHistory))
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldNick(t *testing.T) {
+	cases := map[string]struct {
+		casemapping string
+		in          string
+		expected    string
+	}{
+		"ascii": {
+			casemapping: casemappingASCII,
+			in:          "Alice[]\\~",
+			expected:    "alice[]\\~",
+		},
+		"rfc1459": {
+			casemapping: casemappingRFC1459,
+			in:          "Alice[]\\~",
+			expected:    "alice{}|^",
+		},
+		"strict-rfc1459": {
+			casemapping: casemappingStrictRFC1459,
+			in:          "Alice[]\\~",
+			expected:    "alice{}|~",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, foldNick(tc.casemapping, tc.in))
+		})
+	}
+}
+
+func TestNickMapperKey(t *testing.T) {
+	cases := map[string]struct {
+		network  string
+		msg      *gowon.Message
+		expected string
+	}{
+		"no network": {
+			msg:      &gowon.Message{},
+			expected: "alice",
+		},
+		"configured network": {
+			network:  "Libera",
+			msg:      &gowon.Message{},
+			expected: "libera/alice",
+		},
+		"message network tag": {
+			network:  "Libera",
+			msg:      &gowon.Message{Tags: map[string]string{"network": "OFTC"}},
+			expected: "oftc/alice",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			nm := nickMapper{Casemapping: casemappingRFC1459, Network: tc.network}
+
+			assert.Equal(t, tc.expected, string(nm.Key(nm.MessageNetwork(tc.msg), "Alice")))
+		})
+	}
+}
+
+func TestNormaliseNickKeys(t *testing.T) {
+	store := newMemoryStore()
+
+	linkUser(t, store, "Alice", "AliceOld", 0)
+	linkUser(t, store, "alice", "AliceNew", 0)
+	linkUser(t, store, "Bob[]", "Bob", 2)
+	linkUser(t, store, "OFTC/Carol", "Carol", 0)
+
+	assert.Nil(t, normaliseNickKeys(store, nickMapper{Casemapping: casemappingRFC1459}))
+
+	cases := map[string]string{
+		"Alice":      "",
+		"alice":      "AliceNew",
+		"Bob[]":      "",
+		"bob{}":      "Bob",
+		"OFTC/Carol": "",
+		"oftc/carol": "Carol",
+	}
+
+	for k, expected := range cases {
+		rec, err := store.GetUser(k)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rec.User, k)
+	}
+
+	rec, err := store.GetUser("bob{}")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rec.ID)
+}
+
+func TestNormaliseNickKeysCollision(t *testing.T) {
+	cases := map[string]struct {
+		legacy   userRecord
+		current  userRecord
+		expected userRecord
+	}{
+		"neither verified": {
+			legacy:   userRecord{User: "AliceOld", ID: 1},
+			current:  userRecord{User: "AliceNew", ID: 2},
+			expected: userRecord{User: "AliceNew", ID: 2},
+		},
+		"legacy verified": {
+			legacy:   userRecord{User: "AliceOld", ID: 1, VerifiedID: 1},
+			current:  userRecord{User: "AliceNew", ID: 2},
+			expected: userRecord{User: "AliceOld", ID: 1, VerifiedID: 1},
+		},
+		"both verified": {
+			legacy:   userRecord{User: "AliceOld", ID: 1, VerifiedID: 1},
+			current:  userRecord{User: "AliceNew", ID: 2, VerifiedID: 2},
+			expected: userRecord{User: "AliceNew", ID: 2, VerifiedID: 2},
+		},
+	}
+
+	for name, tc := range cases {
+		for storeName, store := range testStores(t) {
+			t.Run(name+"/"+storeName, func(t *testing.T) {
+				assert.Nil(t, store.UpdateUser("Alice", func(r *userRecord) { *r = tc.legacy }))
+				assert.Nil(t, store.UpdateUser("alice", func(r *userRecord) { *r = tc.current }))
+				assert.Nil(t, store.SetPreference("Alice", "count", "3"))
+				assert.Nil(t, store.SetPreference("Alice", "theme", "mono"))
+				assert.Nil(t, store.SetPreference("alice", "count", "5"))
+
+				assert.Nil(t, normaliseNickKeys(store, nickMapper{Casemapping: casemappingRFC1459}))
+
+				nicks, err := store.Nicks()
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"alice"}, nicks)
+
+				rec, err := store.GetUser("alice")
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expected, rec)
+
+				prefs, err := store.Preferences("alice")
+				assert.Nil(t, err)
+				assert.Equal(t, map[string]string{"count": "5", "theme": "mono"}, prefs)
+			})
+		}
+	}
+}
+
+func TestMigrateNickKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.db")
+	nm := nickMapper{Casemapping: casemappingRFC1459, Network: "Libera"}
+
+	store, err := openBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	linkUser(t, store, "Alice", "Alice", 1)
+	assert.Nil(t, store.SetChannelSetting("#RA", "colours", "off"))
+	assert.Nil(t, store.SetChannelSetting("libera/#ra", "detail", "short"))
+
+	assert.Nil(t, migrateNickKeys(store, nm, path))
+
+	_, err = os.Stat(path + ".nicks.bak")
+	assert.Nil(t, err)
+
+	nicks, err := store.Nicks()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"libera/alice"}, nicks)
+
+	channels, err := store.Channels()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"libera/#ra"}, channels)
+
+	settings, err := store.ChannelSettings("libera/#ra")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"colours": "off", "detail": "short"}, settings)
+
+	assert.Nil(t, os.Remove(path+".nicks.bak"))
+	assert.Nil(t, migrateNickKeys(store, nm, path))
+
+	_, err = os.Stat(path + ".nicks.bak")
+	assert.True(t, os.IsNotExist(err))
+}
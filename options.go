@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRecentAchievements = 1
+	maxRecentAchievements     = 3
+	defaultRecentGames        = 10
+	maxRecentGames            = 10
+
+	defaultSince = 30 * 24 * time.Hour
+	maxSince     = 365 * 24 * time.Hour
+)
+
+func parseCount(s string, def, max int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, localeErrorf("count must be a positive number")
+	}
+
+	if n > max {
+		return 0, localeErrorf("count must be at most %d", max)
+	}
+
+	return n, nil
+}
+
+func parseSince(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	units := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	errFormat := localeErrorf("since must be a number followed by m, h, d or w, e.g. 7d")
+
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, errFormat
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(s[:len(s)-1]))
+	if err != nil || n < 1 {
+		return 0, errFormat
+	}
+
+	if n > int(maxSince/unit) {
+		return 0, localeErrorf("since must be at most 365d")
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+func sinceMinutes(d time.Duration) string {
+	return strconv.Itoa(int(d.Minutes()))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCount(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected int
+		errMsg   string
+	}{
+		"default": {
+			in:       "",
+			expected: 1,
+		},
+		"valid": {
+			in:       "3",
+			expected: 3,
+		},
+		"too many": {
+			in:     "4",
+			errMsg: "count must be at most 3",
+		},
+		"not a number": {
+			in:     "many",
+			errMsg: "count must be a positive number",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := parseCount(tc.in, 1, 3)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected time.Duration
+		errMsg   string
+	}{
+		"default": {
+			in:       "",
+			expected: defaultSince,
+		},
+		"minutes": {
+			in:       "30m",
+			expected: 30 * time.Minute,
+		},
+		"days": {
+			in:       "7d",
+			expected: 7 * 24 * time.Hour,
+		},
+		"weeks": {
+			in:       "2w",
+			expected: 14 * 24 * time.Hour,
+		},
+		"bad unit": {
+			in:     "7y",
+			errMsg: "since must be a number followed by m, h, d or w, e.g. 7d",
+		},
+		"too long": {
+			in:     "400d",
+			errMsg: "since must be at most 365d",
+		},
+		"overflowing weeks": {
+			in:     "15250284452w",
+			errMsg: "since must be at most 365d",
+		},
+		"overflowing minutes": {
+			in:     "9223372036854775807m",
+			errMsg: "since must be at most 365d",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := parseSince(tc.in, defaultSince)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
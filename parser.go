@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type argSpec struct {
+	Name     string
+	Optional bool
+}
+
+type flagSpec struct {
+	Name  string
+	Short string
+	Value string
+}
+
+type commandSpec struct {
+	Name    string
+	Aliases []string
+	Args    []argSpec
+	Flags   []flagSpec
+}
+
+type parsedArgs struct {
+	args  map[string]string
+	flags map[string]string
+}
+
+func (pa *parsedArgs) Arg(name string) string {
+	return pa.args[name]
+}
+
+func (pa *parsedArgs) Flag(name string) string {
+	return pa.flags[name]
+}
+
+func tokenize(in string) ([]string, error) {
+	tokens := []string{}
+
+	var sb strings.Builder
+	inToken := false
+	quote := rune(0)
+	escaped := false
+
+	for _, r := range in {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			sb.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				inToken = false
+			}
+		default:
+			sb.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, localeErrorf("unterminated quote")
+	}
+
+	if inToken {
+		tokens = append(tokens, sb.String())
+	}
+
+	return tokens, nil
+}
+
+func (cs commandSpec) Usage() string {
+	parts := []string{cs.Name}
+
+	for _, a := range cs.Args {
+		if a.Optional {
+			parts = append(parts, fmt.Sprintf("[%s]", a.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("<%s>", a.Name))
+		}
+	}
+
+	for _, f := range cs.Flags {
+		name := "--" + f.Name
+		if f.Short != "" {
+			name = fmt.Sprintf("-%s|--%s", f.Short, f.Name)
+		}
+
+		parts = append(parts, fmt.Sprintf("[%s %s]", name, f.Value))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (cs commandSpec) flag(token string) (flagSpec, bool) {
+	for _, f := range cs.Flags {
+		if token == "--"+f.Name || (f.Short != "" && token == "-"+f.Short) {
+			return f, true
+		}
+	}
+
+	return flagSpec{}, false
+}
+
+func (cs commandSpec) Parse(tokens []string) (*parsedArgs, error) {
+	pa := &parsedArgs{
+		args:  map[string]string{},
+		flags: map[string]string{},
+	}
+
+	positional := []string{}
+	flagsDone := false
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		if flagsDone || !strings.HasPrefix(t, "-") || t == "-" {
+			positional = append(positional, t)
+			continue
+		}
+
+		if t == "--" {
+			flagsDone = true
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(t, "=")
+
+		f, ok := cs.flag(name)
+		if !ok {
+			return nil, localeErrorf("unknown option %s", name)
+		}
+
+		if !hasValue {
+			if i+1 >= len(tokens) {
+				return nil, localeErrorf("option %s needs a value", name)
+			}
+
+			i++
+			value = tokens[i]
+		}
+
+		pa.flags[f.Name] = value
+	}
+
+	required := 0
+	for _, a := range cs.Args {
+		if !a.Optional {
+			required++
+		}
+	}
+
+	if len(positional) > len(cs.Args) {
+		return nil, localeErrorf("unexpected argument %s", positional[len(cs.Args)])
+	}
+
+	optional := max(len(positional)-required, 0)
+
+	for _, a := range cs.Args {
+		if a.Optional {
+			if optional == 0 {
+				continue
+			}
+			optional--
+		}
+
+		if len(positional) == 0 {
+			return nil, localeErrorf("missing %s", a.Name)
+		}
+
+		pa.args[a.Name] = positional[0]
+		positional = positional[1:]
+	}
+
+	return pa, nil
+}
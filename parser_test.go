@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected []string
+		errMsg   string
+	}{
+		"empty": {
+			in:       "",
+			expected: []string{},
+		},
+		"words": {
+			in:       "a  user\t-n 3",
+			expected: []string{"a", "user", "-n", "3"},
+		},
+		"double quotes": {
+			in:       `random "game boy advance" 50`,
+			expected: []string{"random", "game boy advance", "50"},
+		},
+		"single quotes": {
+			in:       `random 'game boy "advance"'`,
+			expected: []string{"random", `game boy "advance"`},
+		},
+		"escaped space": {
+			in:       `random game\ boy`,
+			expected: []string{"random", "game boy"},
+		},
+		"empty quotes": {
+			in:       `a ""`,
+			expected: []string{"a", ""},
+		},
+		"unterminated quote": {
+			in:     `random "game boy`,
+			errMsg: "unterminated quote",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := tokenize(tc.in)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+var testSpec = commandSpec{
+	Name: "between",
+	Args: []argSpec{{Name: "user", Optional: true}, {Name: "from"}, {Name: "to"}},
+	Flags: []flagSpec{
+		{Name: "count", Short: "n", Value: "N"},
+		{Name: "since", Value: "DURATION"},
+	},
+}
+
+func TestCommandSpecUsage(t *testing.T) {
+	assert.Equal(t, "between [user] <from> <to> [-n|--count N] [--since DURATION]", testSpec.Usage())
+}
+
+func TestCommandSpecParse(t *testing.T) {
+	cases := map[string]struct {
+		in     []string
+		args   map[string]string
+		flags  map[string]string
+		errMsg string
+	}{
+		"required only": {
+			in:    []string{"2024-08-01", "2024-08-07"},
+			args:  map[string]string{"from": "2024-08-01", "to": "2024-08-07"},
+			flags: map[string]string{},
+		},
+		"optional filled first": {
+			in:    []string{"user", "2024-08-01", "2024-08-07"},
+			args:  map[string]string{"user": "user", "from": "2024-08-01", "to": "2024-08-07"},
+			flags: map[string]string{},
+		},
+		"flags": {
+			in:    []string{"-n", "3", "2024-08-01", "--since=7d", "2024-08-07"},
+			args:  map[string]string{"from": "2024-08-01", "to": "2024-08-07"},
+			flags: map[string]string{"count": "3", "since": "7d"},
+		},
+		"end of flags": {
+			in:    []string{"--", "-user-", "2024-08-01", "2024-08-07"},
+			args:  map[string]string{"user": "-user-", "from": "2024-08-01", "to": "2024-08-07"},
+			flags: map[string]string{},
+		},
+		"missing argument": {
+			in:     []string{"2024-08-01"},
+			errMsg: "missing to",
+		},
+		"too many arguments": {
+			in:     []string{"user", "2024-08-01", "2024-08-07", "extra"},
+			errMsg: "unexpected argument extra",
+		},
+		"unknown flag": {
+			in:     []string{"--colour", "red"},
+			errMsg: "unknown option --colour",
+		},
+		"flag without value": {
+			in:     []string{"2024-08-01", "2024-08-07", "-n"},
+			errMsg: "option -n needs a value",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := testSpec.Parse(tc.in)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.args, out.args)
+			assert.Equal(t, tc.flags, out.flags)
+		})
+	}
+}
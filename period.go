@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+const (
+	dateFormat = "2006-01-02"
+
+	maxPeriodTopGames = 3
+
+	periodColour = "period"
+)
+
+type Period struct {
+	Label string
+	From  time.Time
+	To    time.Time
+	Day   bool
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func namedPeriod(name string, loc *time.Location) (Period, bool) {
+	today := startOfDay(now().In(loc))
+
+	day := loc == time.UTC
+
+	switch name {
+	case "today":
+		return Period{Label: "today", From: today, To: today.AddDate(0, 0, 1).Add(-time.Second), Day: day}, true
+	case "yesterday":
+		y := today.AddDate(0, 0, -1)
+		return Period{Label: "yesterday", From: y, To: today.Add(-time.Second), Day: day}, true
+	case "week":
+		return Period{Label: "this week", From: today.AddDate(0, 0, -6), To: now().In(loc)}, true
+	}
+
+	return Period{}, false
+}
+
+func parsePeriod(from, to string, loc *time.Location) (Period, error) {
+	f, err := time.ParseInLocation(dateFormat, from, loc)
+	if err != nil {
+		return Period{}, localeErrorf("dates must be in YYYY-MM-DD format")
+	}
+
+	t, err := time.ParseInLocation(dateFormat, to, loc)
+	if err != nil {
+		return Period{}, localeErrorf("dates must be in YYYY-MM-DD format")
+	}
+
+	if t.Before(f) {
+		return Period{}, localeErrorf("end date %s is before start date %s", to, from)
+	}
+
+	return Period{
+		From: f,
+		To:   t.AddDate(0, 0, 1).Add(-time.Second),
+	}, nil
+}
+
+func (p Period) Describe(l *Locale) string {
+	if p.Label == "" {
+		return l.Sprintf("%s to %s", p.From.Format(dateFormat), p.To.Format(dateFormat))
+	}
+
+	return l.Translate(p.Label)
+}
+
+func raAchievementsInPeriod(client *req.Client, user string, p Period) ([]Achievement, error) {
+	var j []Achievement
+
+	r := client.R().
+		SetQueryParam("u", user).
+		SetSuccessResult(&j)
+
+	var err error
+
+	if p.Day {
+		_, err = r.SetQueryParam("d", p.From.Format(dateFormat)).Get(raAchievementsOnDayURL)
+	} else {
+		_, err = r.
+			SetQueryParam("f", strconv.FormatInt(p.From.Unix(), 10)).
+			SetQueryParam("t", strconv.FormatInt(p.To.Unix(), 10)).
+			Get(raAchievementsBetweenURL)
+	}
+
+	return j, err
+}
+
+type gameCount struct {
+	Title string
+	Count int
+}
+
+type AchievementSummary struct {
+	Count    int
+	Points   int
+	Hardcore int
+	Games    []gameCount
+}
+
+func summariseAchievements(achievements []Achievement) AchievementSummary {
+	as := AchievementSummary{}
+	counts := map[string]int{}
+
+	for _, a := range achievements {
+		as.Count++
+		as.Points += a.Points
+
+		if a.HardcoreMode == 1 {
+			as.Hardcore++
+		}
+
+		counts[a.GameTitle]++
+	}
+
+	for title, count := range counts {
+		as.Games = append(as.Games, gameCount{Title: title, Count: count})
+	}
+
+	sort.Slice(as.Games, func(i, j int) bool {
+		if as.Games[i].Count == as.Games[j].Count {
+			return as.Games[i].Title < as.Games[j].Title
+		}
+		return as.Games[i].Count > as.Games[j].Count
+	})
+
+	return as
+}
+
+func raPeriod(client *req.Client, user string, p Period, prefs Preferences) (string, error) {
+	achievements, err := raAchievementsInPeriod(client, user, p)
+	if err != nil {
+		return "", err
+	}
+
+	if prefs.HideSoftcore() {
+		achievements = hardcoreAchievements(achievements)
+	}
+
+	if len(achievements) == 0 {
+		return prefs.Locale.Sprintf("No achievements found for user %s %s", user, p.Describe(prefs.Locale)), nil
+	}
+
+	as := summariseAchievements(achievements)
+
+	games := as.Games
+	if len(games) > maxPeriodTopGames {
+		games = games[:maxPeriodTopGames]
+	}
+
+	topGames := []string{}
+	for _, g := range games {
+		topGames = append(topGames, fmt.Sprintf("%s (%d)", g.Title, g.Count))
+	}
+
+	return renderTemplate("period", prefs, struct {
+		User  string
+		Label string
+		Period
+		AchievementSummary
+		TopGames []string
+	}{user, p.Describe(prefs.Locale), p, as, topGames})
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedPeriod(t *testing.T) {
+	cases := map[string]struct {
+		in    string
+		from  string
+		to    string
+		day   bool
+		found bool
+	}{
+		"today": {
+			in:    "today",
+			from:  "2024-08-31 00:00:00",
+			to:    "2024-08-31 23:59:59",
+			day:   true,
+			found: true,
+		},
+		"yesterday": {
+			in:    "yesterday",
+			from:  "2024-08-30 00:00:00",
+			to:    "2024-08-30 23:59:59",
+			day:   true,
+			found: true,
+		},
+		"week": {
+			in:    "week",
+			from:  "2024-08-25 00:00:00",
+			to:    "2024-08-31 17:00:00",
+			day:   false,
+			found: true,
+		},
+		"unknown": {
+			in:    "fortnight",
+			found: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			now = func() time.Time { n, _ := time.Parse(timeDateFormat, "2024-08-31 17:00:00"); return n }
+
+			p, found := namedPeriod(tc.in, time.UTC)
+
+			assert.Equal(t, tc.found, found)
+
+			if tc.found {
+				assert.Equal(t, tc.from, p.From.Format(timeDateFormat))
+				assert.Equal(t, tc.to, p.To.Format(timeDateFormat))
+				assert.Equal(t, tc.day, p.Day)
+			}
+		})
+	}
+}
+
+func TestNamedPeriodTimezone(t *testing.T) {
+	fixNow(t, "2024-08-31 23:30:00")
+
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	p, found := namedPeriod("today", loc)
+	assert.True(t, found)
+	assert.Equal(t, "2024-09-01", p.From.Format(dateFormat))
+	assert.False(t, p.Day)
+	assert.Equal(t, time.Date(2024, 8, 31, 15, 0, 0, 0, time.UTC), p.From.UTC())
+	assert.Equal(t, time.Date(2024, 9, 1, 14, 59, 59, 0, time.UTC), p.To.UTC())
+}
+
+func TestParsePeriod(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	cases := map[string]struct {
+		from   string
+		to     string
+		loc    *time.Location
+		label  string
+		start  time.Time
+		errMsg string
+	}{
+		"valid": {
+			from:  "2024-08-01",
+			to:    "2024-08-07",
+			label: "2024-08-01 to 2024-08-07",
+			start: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"timezone": {
+			from:  "2024-08-01",
+			to:    "2024-08-07",
+			loc:   tokyo,
+			label: "2024-08-01 to 2024-08-07",
+			start: time.Date(2024, 7, 31, 15, 0, 0, 0, time.UTC),
+		},
+		"invalid date": {
+			from:   "yesterday",
+			to:     "2024-08-07",
+			errMsg: "dates must be in YYYY-MM-DD format",
+		},
+		"reversed": {
+			from:   "2024-08-07",
+			to:     "2024-08-01",
+			errMsg: "end date 2024-08-01 is before start date 2024-08-07",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			loc := tc.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			p, err := parsePeriod(tc.from, tc.to, loc)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.label, p.Describe(nil))
+			assert.Equal(t, tc.start, p.From.UTC())
+		})
+	}
+}
+
+func TestPeriodDescribe(t *testing.T) {
+	de, _ := findLocale("de")
+
+	p, err := parsePeriod("2024-08-01", "2024-08-07", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-08-01 bis 2024-08-07", p.Describe(de))
+
+	p, _ = namedPeriod("yesterday", time.UTC)
+	assert.Equal(t, "gestern", p.Describe(de))
+}
+
+func TestSummariseAchievements(t *testing.T) {
+	j := openTestFile(t, "API_GetAchievementsEarnedBetween", "achievements.json")
+	achievements := []Achievement{}
+	err := json.Unmarshal(j, &achievements)
+	assert.Nil(t, err)
+
+	as := summariseAchievements(achievements)
+
+	assert.Equal(t, 3, as.Count)
+	assert.Equal(t, 40, as.Points)
+	assert.Equal(t, 2, as.Hardcore)
+	assert.Equal(t, []gameCount{{Title: "game 1", Count: 2}, {Title: "game 2", Count: 1}}, as.Games)
+}
+
+func TestRaPeriod(t *testing.T) {
+	cases := map[string]struct {
+		endpoint string
+		url      string
+		jsonfn   string
+		period   string
+		expected string
+		err      error
+	}{
+		"today": {
+			endpoint: "API_GetAchievementsEarnedOnDay",
+			url:      raAchievementsOnDayURL,
+			jsonfn:   "achievements.json",
+			period:   "today",
+			expected: "user | {orange}today{clear} | {cyan}1 achievement{clear} | {green}5 points{clear} | {yellow}100% hardcore{clear} | {magenta}Top games: game 1 (1){clear}",
+			err:      nil,
+		},
+		"nothing today": {
+			endpoint: "API_GetAchievementsEarnedOnDay",
+			url:      raAchievementsOnDayURL,
+			jsonfn:   "no_achievements.json",
+			period:   "today",
+			expected: "No achievements found for user user today",
+			err:      nil,
+		},
+		"week": {
+			endpoint: "API_GetAchievementsEarnedBetween",
+			url:      raAchievementsBetweenURL,
+			jsonfn:   "achievements.json",
+			period:   "week",
+			expected: "user | {orange}this week{clear} | {cyan}3 achievements{clear} | {green}40 points{clear} | {yellow}66% hardcore{clear} | {magenta}Top games: game 1 (2), game 2 (1){clear}",
+			err:      nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			now = func() time.Time { n, _ := time.Parse(timeDateFormat, "2024-08-31 17:00:00"); return n }
+			json := openTestFile(t, tc.endpoint, tc.jsonfn)
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", tc.url, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, json)
+				return resp, nil
+			})
+
+			p, _ := namedPeriod(tc.period, time.UTC)
+			out, err := raPeriod(client, "user", p, defaultPreferences())
+
+			assert.Equal(t, tc.expected, out)
+			assert.ErrorIs(t, tc.err, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gowon-irc/go-gowon"
+)
+
+type permissions struct {
+	Admins  []string
+	Chanops []string
+}
+
+func wildcardMatch(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+
+	switch pattern[0] {
+	case '*':
+		for i := 0; i <= len(s); i++ {
+			if wildcardMatch(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return s != "" && wildcardMatch(pattern[1:], s[1:])
+	}
+
+	return s != "" && pattern[0] == s[0] && wildcardMatch(pattern[1:], s[1:])
+}
+
+func matchesMask(nm nickMapper, mask string, m *gowon.Message) bool {
+	if !strings.ContainsAny(mask, "!@") {
+		return foldNick(nm.Casemapping, mask) == foldNick(nm.Casemapping, m.Nick)
+	}
+
+	if m.User == "" && m.Host == "" {
+		return false
+	}
+
+	source := m.Nick + "!" + m.User + "@" + m.Host
+
+	return wildcardMatch(foldNick(nm.Casemapping, mask), foldNick(nm.Casemapping, source))
+}
+
+func (p permissions) IsAdmin(nm nickMapper, m *gowon.Message) bool {
+	for _, mask := range p.Admins {
+		if matchesMask(nm, mask, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p permissions) IsChanop(nm nickMapper, network, channel string, m *gowon.Message) bool {
+	if p.IsAdmin(nm, m) {
+		return true
+	}
+
+	for _, op := range p.Chanops {
+		mask := op
+
+		if c, rest, scoped := strings.Cut(op, ":"); scoped && isChannel(c) {
+			if string(nm.Key(network, c)) != string(nm.Key(network, channel)) {
+				continue
+			}
+			mask = rest
+		}
+
+		if matchesMask(nm, mask, m) {
+			return true
+		}
+	}
+
+	return false
+}
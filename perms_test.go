@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gowon-irc/go-gowon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	cases := map[string]struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		"exact":          {pattern: "nick!user@host", s: "nick!user@host", expected: true},
+		"star":           {pattern: "*!*@host.example", s: "nick!user@host.example", expected: true},
+		"question":       {pattern: "nic?!*@*", s: "nick!user@host", expected: true},
+		"slash in host":  {pattern: "*!*@user/nick", s: "nick!~nick@user/nick", expected: true},
+		"no match":       {pattern: "*!*@other", s: "nick!user@host", expected: false},
+		"empty":          {pattern: "", s: "", expected: true},
+		"trailing chars": {pattern: "nick", s: "nicks", expected: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, wildcardMatch(tc.pattern, tc.s))
+		})
+	}
+}
+
+func TestIsChanop(t *testing.T) {
+	nm := nickMapper{Casemapping: casemappingRFC1459}
+	perms := permissions{Chanops: []string{"Alice", "#ra:bob[]", "*!*@trusted.example"}}
+
+	cases := map[string]struct {
+		channel  string
+		msg      *gowon.Message
+		expected bool
+	}{
+		"global nick": {
+			channel:  "#main",
+			msg:      &gowon.Message{Nick: "alice"},
+			expected: true,
+		},
+		"scoped nick": {
+			channel:  "#RA",
+			msg:      &gowon.Message{Nick: "Bob{}"},
+			expected: true,
+		},
+		"scoped nick other channel": {
+			channel:  "#main",
+			msg:      &gowon.Message{Nick: "bob[]"},
+			expected: false,
+		},
+		"hostmask": {
+			channel:  "#main",
+			msg:      &gowon.Message{Nick: "carol", User: "carol", Host: "Trusted.Example"},
+			expected: true,
+		},
+		"hostmask without host": {
+			channel:  "#main",
+			msg:      &gowon.Message{Nick: "carol"},
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, perms.IsChanop(nm, "", tc.channel, tc.msg))
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	nm := nickMapper{Casemapping: casemappingRFC1459}
+	perms := permissions{Admins: []string{"root", "*!*@admin.example"}}
+
+	assert.True(t, perms.IsAdmin(nm, &gowon.Message{Nick: "Root"}))
+	assert.True(t, perms.IsAdmin(nm, &gowon.Message{Nick: "x", User: "x", Host: "admin.example"}))
+	assert.False(t, perms.IsAdmin(nm, &gowon.Message{Nick: "x", User: "x", Host: "other.example"}))
+	assert.True(t, perms.IsChanop(nm, "", "#any", &gowon.Message{Nick: "root"}))
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	prefDetail   = "detail"
+	prefSoftcore = "softcore"
+	prefConsoles = "consoles"
+	prefTimezone = "timezone"
+	prefTheme    = "theme"
+	prefDates    = "dates"
+	prefLanguage = "language"
+
+	prefDefault = "default"
+
+	detailFull  = "full"
+	detailShort = "short"
+
+	softcoreShow = "show"
+	softcoreHide = "hide"
+
+	datesRelative = "relative"
+	datesAbsolute = "absolute"
+)
+
+type Preferences struct {
+	Detail   string
+	Softcore string
+	Consoles []string
+	Timezone *time.Location
+	Theme    *Theme
+	Dates    string
+	Locale   *Locale
+
+	capture *capturedOutput
+}
+
+func defaultPreferences() Preferences {
+	return Preferences{
+		Detail:   detailFull,
+		Softcore: softcoreShow,
+		Timezone: time.UTC,
+		Theme:    defaultTheme,
+		Dates:    datesRelative,
+		Locale:   defaultLocale,
+	}
+}
+
+func (p Preferences) Short() bool {
+	return p.Detail == detailShort
+}
+
+func (p Preferences) HideSoftcore() bool {
+	return p.Softcore == softcoreHide
+}
+
+type settingSpec[T any] struct {
+	Name   string
+	Values string
+	Apply  func(s *T, value string) error
+}
+
+func choiceSetting[T any](set func(s *T, value string), choices ...string) func(*T, string) error {
+	return func(s *T, value string) error {
+		for _, c := range choices {
+			if value == c {
+				set(s, value)
+				return nil
+			}
+		}
+
+		return localeErrorf("value must be one of %s", strings.Join(choices, ", "))
+	}
+}
+
+func findSetting[T any](specs []settingSpec[T], name string) (settingSpec[T], bool) {
+	for _, s := range specs {
+		if s.Name == strings.ToLower(name) {
+			return s, true
+		}
+	}
+
+	return settingSpec[T]{}, false
+}
+
+func settingNames[T any](specs []settingSpec[T]) []string {
+	names := []string{}
+
+	for _, s := range specs {
+		names = append(names, s.Name)
+	}
+
+	return names
+}
+
+func settingValues[T any](l *Locale, specs []settingSpec[T]) []string {
+	values := []string{}
+
+	for _, s := range specs {
+		values = append(values, fmt.Sprintf("%s (%s)", s.Name, l.Translate(s.Values)))
+	}
+
+	return values
+}
+
+func applySettings[T any](specs []settingSpec[T], settings *T, stored map[string]string) {
+	for _, s := range specs {
+		v, ok := stored[s.Name]
+		if !ok {
+			continue
+		}
+
+		if err := s.Apply(settings, v); err != nil {
+			log.Printf("ignoring stored %s setting %q: %s\n", s.Name, v, err)
+		}
+	}
+}
+
+func storedSettings[T any](specs []settingSpec[T], stored map[string]string) []string {
+	set := []string{}
+
+	for _, s := range specs {
+		if v, ok := stored[s.Name]; ok {
+			set = append(set, fmt.Sprintf("%s=%s", s.Name, v))
+		}
+	}
+
+	return set
+}
+
+var preferenceSpecs = []settingSpec[Preferences]{
+	{
+		Name:   prefDetail,
+		Values: "full|short",
+		Apply: choiceSetting(func(p *Preferences, v string) {
+			p.Detail = v
+		}, detailFull, detailShort),
+	},
+	{
+		Name:   prefSoftcore,
+		Values: "show|hide",
+		Apply: choiceSetting(func(p *Preferences, v string) {
+			p.Softcore = v
+		}, softcoreShow, softcoreHide),
+	},
+	{
+		Name:   prefConsoles,
+		Values: "comma separated consoles, e.g. snes,gba",
+		Apply: func(p *Preferences, v string) error {
+			consoles := []string{}
+
+			for _, c := range strings.Split(v, ",") {
+				if c = strings.TrimSpace(c); c != "" {
+					consoles = append(consoles, c)
+				}
+			}
+
+			if len(consoles) == 0 {
+				return localeErrorf("at least one console is needed")
+			}
+
+			p.Consoles = consoles
+			return nil
+		},
+	},
+	{
+		Name:   prefTimezone,
+		Values: "timezone name, e.g. Europe/London",
+		Apply: func(p *Preferences, v string) error {
+			loc, err := time.LoadLocation(v)
+			if err != nil || v == "" || strings.EqualFold(v, "local") {
+				return localeErrorf("unknown timezone %s", v)
+			}
+
+			p.Timezone = loc
+			return nil
+		},
+	},
+	{
+		Name:   prefTheme,
+		Values: "theme name, e.g. monochrome",
+		Apply: func(p *Preferences, v string) error {
+			t, err := themeSetting(v)
+			if err != nil {
+				return err
+			}
+
+			p.Theme = t
+			return nil
+		},
+	},
+	{
+		Name:   prefDates,
+		Values: "relative|absolute",
+		Apply: choiceSetting(func(p *Preferences, v string) {
+			p.Dates = v
+		}, datesRelative, datesAbsolute),
+	},
+	{
+		Name:   prefLanguage,
+		Values: "language code, e.g. de",
+		Apply: func(p *Preferences, v string) error {
+			l, err := localeSetting(v)
+			if err != nil {
+				return err
+			}
+
+			p.Locale = l
+			return nil
+		},
+	},
+}
+
+func parsePreferences(stored map[string]string) Preferences {
+	p := defaultPreferences()
+	applySettings(preferenceSpecs, &p, stored)
+	return p
+}
+
+func prefHandler(ctx *commandContext, args *parsedArgs) (string, error) {
+	nick := ctx.msg.Nick
+	key := ctx.nickKey(nick)
+
+	stored, err := ctx.store.Preferences(key)
+	if err != nil {
+		return "", err
+	}
+
+	prefs, err := ctx.preferences()
+	if err != nil {
+		return "", err
+	}
+
+	name := args.Arg("key")
+
+	if name == "" {
+		set := storedSettings(preferenceSpecs, stored)
+
+		if len(set) == 0 {
+			return ctx.sprintf("%s has no preferences set, available: %s", nick, strings.Join(settingValues(prefs.Locale, preferenceSpecs), ", "))
+		}
+
+		return ctx.sprintf("%s's preferences: %s", nick, strings.Join(set, ", "))
+	}
+
+	s, ok := findSetting(preferenceSpecs, name)
+	if !ok {
+		return ctx.sprintf("Error: unknown preference %s, available: %s", name, strings.Join(settingNames(preferenceSpecs), ", "))
+	}
+
+	value := args.Arg("value")
+
+	switch value {
+	case "":
+		if v, ok := stored[s.Name]; ok {
+			return ctx.sprintf("%s's %s preference is %s", nick, s.Name, v)
+		}
+
+		return ctx.sprintf("%s's %s preference is not set (%s)", nick, s.Name, prefs.Locale.Translate(s.Values))
+	case prefDefault:
+		if err := ctx.store.DeletePreference(key, s.Name); err != nil {
+			return "", err
+		}
+
+		return ctx.sprintf("reset %s's %s preference", nick, s.Name)
+	}
+
+	p := defaultPreferences()
+	if err := s.Apply(&p, value); err != nil {
+		return ctx.sprintf("Error: %s", err)
+	}
+
+	if err := ctx.store.SetPreference(key, s.Name, value); err != nil {
+		return "", err
+	}
+
+	return ctx.sprintf("set %s's %s preference to %s", nick, s.Name, value)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePreferences(t *testing.T) {
+	cases := map[string]struct {
+		stored   map[string]string
+		detail   string
+		softcore string
+		consoles []string
+		timezone string
+	}{
+		"defaults": {
+			stored:   map[string]string{},
+			detail:   detailFull,
+			softcore: softcoreShow,
+			timezone: "UTC",
+		},
+		"set": {
+			stored:   map[string]string{"detail": "short", "softcore": "hide", "consoles": "snes, gba", "timezone": "Europe/London"},
+			detail:   detailShort,
+			softcore: softcoreHide,
+			consoles: []string{"snes", "gba"},
+			timezone: "Europe/London",
+		},
+		"invalid ignored": {
+			stored:   map[string]string{"detail": "loud", "timezone": "Nowhere/Special"},
+			detail:   detailFull,
+			softcore: softcoreShow,
+			timezone: "UTC",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := parsePreferences(tc.stored)
+
+			assert.Equal(t, tc.detail, p.Detail)
+			assert.Equal(t, tc.softcore, p.Softcore)
+			assert.Equal(t, tc.consoles, p.Consoles)
+			assert.Equal(t, tc.timezone, p.Timezone.String())
+		})
+	}
+}
+
+func TestPrefHandler(t *testing.T) {
+	store := newMemoryStore()
+	ctx := testContext(nil, store, "Nick")
+	r := defaultCommands()
+	ctx.commands = r
+
+	steps := []struct {
+		args     string
+		expected string
+	}{
+		{
+			args:     "pref",
+			expected: "Nick has no preferences set, available: detail (full|short), softcore (show|hide), consoles (comma separated consoles, e.g. snes,gba), timezone (timezone name, e.g. Europe/London), theme (theme name, e.g. monochrome), dates (relative|absolute), language (language code, e.g. de)",
+		},
+		{
+			args:     "pref detail short",
+			expected: "set Nick's detail preference to short",
+		},
+		{
+			args:     "pref detail loud",
+			expected: "Error: value must be one of full, short",
+		},
+		{
+			args:     "pref timezone Nowhere/Special",
+			expected: "Error: unknown timezone Nowhere/Special",
+		},
+		{
+			args:     `pref consoles "snes, gba"`,
+			expected: "set Nick's consoles preference to snes, gba",
+		},
+		{
+			args:     "pref detail",
+			expected: "Nick's detail preference is short",
+		},
+		{
+			args:     "pref",
+			expected: "Nick's preferences: detail=short, consoles=snes, gba",
+		},
+		{
+			args:     "pref detail default",
+			expected: "reset Nick's detail preference",
+		},
+		{
+			args:     "pref detail",
+			expected: "Nick's detail preference is not set (full|short)",
+		},
+		{
+			args:     "pref theme neon",
+			expected: "Error: unknown theme neon, available: default, high-contrast, monochrome",
+		},
+		{
+			args:     "pref colour red",
+			expected: "Error: unknown preference colour, available: detail, softcore, consoles, timezone, theme, dates, language",
+		},
+	}
+
+	for _, s := range steps {
+		ctx.msg.Args = s.args
+
+		out, err := r.Dispatch(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, s.expected, out, s.args)
+	}
+
+	prefs, err := store.Preferences("nick")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"consoles": "snes, gba"}, prefs)
+}
+
+func TestPreferencesTheme(t *testing.T) {
+	store := newMemoryStore()
+	assert.Nil(t, store.SetChannelSetting("#ra", chanTheme, monochromeThemeName))
+	assert.Nil(t, store.SetPreference("colourful", prefTheme, defaultThemeName))
+
+	r := newCommandRegistry(&command{
+		commandSpec: commandSpec{Name: "echo"},
+		Handler: func(ctx *commandContext, args *parsedArgs) (string, error) {
+			p, err := ctx.preferences()
+			return p.Theme.Name, err
+		},
+	})
+
+	cases := map[string]struct {
+		nick     string
+		dest     string
+		expected string
+	}{
+		"channel theme": {
+			nick:     "nick",
+			dest:     "#ra",
+			expected: monochromeThemeName,
+		},
+		"nick theme wins": {
+			nick:     "colourful",
+			dest:     "#ra",
+			expected: defaultThemeName,
+		},
+		"default": {
+			nick:     "nick",
+			dest:     "nick",
+			expected: defaultThemeName,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(nil, store, tc.nick)
+			ctx.msg.Dest = tc.dest
+			ctx.msg.Args = "echo"
+
+			out, err := r.Dispatch(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+var (
+	randIntn = rand.Intn
+
+	gameListCache = newCache[int, []GameListEntry](24 * time.Hour)
+)
+
+type GameListEntry struct {
+	ID              int    `json:"ID"`
+	Title           string `json:"Title"`
+	ConsoleID       int    `json:"ConsoleID"`
+	ConsoleName     string `json:"ConsoleName"`
+	NumAchievements int    `json:"NumAchievements"`
+	Points          int    `json:"Points"`
+}
+
+func formatGame(g GameListEntry, p Preferences) (string, error) {
+	return renderTemplate("game", p, g)
+}
+
+func raGameList(client *req.Client, consoleID int) ([]GameListEntry, error) {
+	if gl, ok := gameListCache.Get(consoleID); ok {
+		return gl, nil
+	}
+
+	var j []GameListEntry
+
+	_, err := client.R().
+		SetQueryParam("i", strconv.Itoa(consoleID)).
+		SetQueryParam("f", "1").
+		SetSuccessResult(&j).
+		Get(raGameListURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gameListCache.Set(consoleID, j)
+
+	return j, nil
+}
+
+const completionProgressPageSize = 500
+
+type CompletionProgress struct {
+	Count   int `json:"Count"`
+	Total   int `json:"Total"`
+	Results []struct {
+		GameID             int    `json:"GameID"`
+		Title              string `json:"Title"`
+		ConsoleID          int    `json:"ConsoleID"`
+		ConsoleName        string `json:"ConsoleName"`
+		MaxPossible        int    `json:"MaxPossible"`
+		NumAwarded         int    `json:"NumAwarded"`
+		NumAwardedHardcore int    `json:"NumAwardedHardcore"`
+		HighestAwardKind   string `json:"HighestAwardKind"`
+	} `json:"Results"`
+}
+
+func raCompletionProgress(client *req.Client, user string) (CompletionProgress, error) {
+	var cp CompletionProgress
+
+	for {
+		var j CompletionProgress
+
+		_, err := client.R().
+			SetQueryParam("u", user).
+			SetQueryParam("c", strconv.Itoa(completionProgressPageSize)).
+			SetQueryParam("o", strconv.Itoa(len(cp.Results))).
+			SetSuccessResult(&j).
+			Get(raCompletionProgressURL)
+
+		if err != nil {
+			return cp, err
+		}
+
+		cp.Total = j.Total
+		cp.Results = append(cp.Results, j.Results...)
+		cp.Count = len(cp.Results)
+
+		if j.Count == 0 || cp.Count >= cp.Total {
+			return cp, nil
+		}
+	}
+}
+
+func shuffleConsoles(consoles []Console) []Console {
+	out := append([]Console{}, consoles...)
+
+	for i := len(out) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return out
+}
+
+func unplayedGames(games []GameListEntry, played map[int]bool, maxAchievements int) []GameListEntry {
+	candidates := []GameListEntry{}
+	for _, g := range games {
+		if g.NumAchievements == 0 || played[g.ID] {
+			continue
+		}
+
+		if maxAchievements > 0 && g.NumAchievements > maxAchievements {
+			continue
+		}
+
+		candidates = append(candidates, g)
+	}
+
+	return candidates
+}
+
+func raRandomGame(client *req.Client, user, consoleName string, maxAchievements int, p Preferences) (string, error) {
+	consoles, err := raConsoles(client)
+	if err != nil {
+		return "", err
+	}
+
+	if len(consoles) == 0 {
+		return p.Locale.Translate("No consoles found"), nil
+	}
+
+	if consoleName != "" {
+		c, ok := findConsole(consoles, consoleName)
+		if !ok {
+			return p.Locale.Sprintf("Console %s not found", consoleName), nil
+		}
+		consoles = []Console{c}
+	} else {
+		consoles = shuffleConsoles(consoles)
+	}
+
+	played := map[int]bool{}
+
+	if user != "" {
+		cp, err := raCompletionProgress(client, user)
+		if err != nil {
+			return "", err
+		}
+
+		for _, g := range cp.Results {
+			played[g.GameID] = true
+		}
+	}
+
+	for _, console := range consoles {
+		games, err := raGameList(client, console.ID)
+		if err != nil {
+			return "", err
+		}
+
+		candidates := unplayedGames(games, played, maxAchievements)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		return renderTemplate("random", p, struct {
+			User string
+			Game GameListEntry
+		}{user, candidates[randIntn(len(candidates))]})
+	}
+
+	if consoleName != "" {
+		return p.Locale.Sprintf("No unplayed games found for %s", consoles[0].Name), nil
+	}
+
+	return p.Locale.Translate("No unplayed games found"), nil
+}
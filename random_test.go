@@ -0,0 +1,179 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatGame(t *testing.T) {
+	cases := map[string]struct {
+		in       GameListEntry
+		expected string
+	}{
+		"game": {
+			in: GameListEntry{
+				Title:           "game",
+				ConsoleName:     "console",
+				NumAchievements: 10,
+				Points:          100,
+			},
+			expected: "{magenta}game (console){clear} | {cyan}10 achievements{clear} | {green}100 points{clear}",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := formatGame(tc.in, defaultPreferences())
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestRaRandomGame(t *testing.T) {
+	cases := map[string]struct {
+		user            string
+		console         string
+		maxAchievements int
+		noGames         []string
+		expected        string
+		err             error
+	}{
+		"no user": {
+			user:     "",
+			console:  "5",
+			expected: "Random retro game: {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {cyan}157 achievements{clear} | {green}1,369 points{clear}",
+			err:      nil,
+		},
+		"console by name": {
+			user:     "",
+			console:  "game boy advance",
+			expected: "Random retro game: {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {cyan}157 achievements{clear} | {green}1,369 points{clear}",
+			err:      nil,
+		},
+		"console by alias": {
+			user:     "",
+			console:  "gba",
+			expected: "Random retro game: {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {cyan}157 achievements{clear} | {green}1,369 points{clear}",
+			err:      nil,
+		},
+		"excludes played games": {
+			user:     "user",
+			console:  "5",
+			expected: "user's random retro game: {magenta}Advance Wars (Game Boy Advance){clear} | {cyan}80 achievements{clear} | {green}700 points{clear}",
+			err:      nil,
+		},
+		"max achievements": {
+			user:            "user",
+			console:         "5",
+			maxAchievements: 50,
+			expected:        "user's random retro game: {magenta}Kirby: Nightmare in Dream Land (Game Boy Advance){clear} | {cyan}30 achievements{clear} | {green}400 points{clear}",
+			err:             nil,
+		},
+		"no matching games": {
+			user:            "user",
+			console:         "5",
+			maxAchievements: 10,
+			expected:        "No unplayed games found for Game Boy Advance",
+			err:             nil,
+		},
+		"any console": {
+			user:     "",
+			console:  "",
+			expected: "Random retro game: {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {cyan}157 achievements{clear} | {green}1,369 points{clear}",
+			err:      nil,
+		},
+		"falls back to other consoles": {
+			user:     "user",
+			console:  "",
+			noGames:  []string{"5", "12"},
+			expected: "user's random retro game: {magenta}Advance Wars (Game Boy Advance){clear} | {cyan}80 achievements{clear} | {green}700 points{clear}",
+			err:      nil,
+		},
+		"no matching games on any console": {
+			user:            "user",
+			console:         "",
+			maxAchievements: 10,
+			expected:        "No unplayed games found",
+			err:             nil,
+		},
+		"unknown console": {
+			user:     "user",
+			console:  "toaster",
+			expected: "Console toaster not found",
+			err:      nil,
+		},
+	}
+
+	intn := randIntn
+	t.Cleanup(func() { randIntn = intn })
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			randIntn = func(int) int { return 0 }
+			consoleCache.Clear()
+			gameListCache.Clear()
+
+			cJson := openTestFile(t, "API_GetConsoleIDs", "consoles.json")
+			glJson := openTestFile(t, "API_GetGameList", "games.json")
+			cpJson := openTestFile(t, "API_GetUserCompletionProgress", "progress.json")
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+
+			httpmock.RegisterResponder("GET", raConsoleIDsURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, cJson)
+				return resp, nil
+			})
+
+			httpmock.RegisterResponder("GET", raGameListURL, func(request *http.Request) (*http.Response, error) {
+				if slices.Contains(tc.noGames, request.URL.Query().Get("i")) {
+					return httpmock.NewStringResponse(http.StatusOK, "[]"), nil
+				}
+
+				resp := httpmock.NewBytesResponse(http.StatusOK, glJson)
+				return resp, nil
+			})
+
+			httpmock.RegisterResponder("GET", raCompletionProgressURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, cpJson)
+				return resp, nil
+			})
+
+			out, err := raRandomGame(client, tc.user, tc.console, tc.maxAchievements, defaultPreferences())
+
+			assert.Equal(t, tc.expected, out)
+			assert.ErrorIs(t, tc.err, err)
+		})
+	}
+}
+
+func TestRaCompletionProgressPages(t *testing.T) {
+	pages := map[string][]byte{
+		"0": openTestFile(t, "API_GetUserCompletionProgress", "page_1.json"),
+		"2": openTestFile(t, "API_GetUserCompletionProgress", "page_2.json"),
+	}
+
+	offsets := []string{}
+
+	client := req.C()
+	httpmock.ActivateNonDefault(client.GetClient())
+	httpmock.RegisterResponder("GET", raCompletionProgressURL, func(request *http.Request) (*http.Response, error) {
+		offsets = append(offsets, request.URL.Query().Get("o"))
+		resp := httpmock.NewBytesResponse(http.StatusOK, pages[request.URL.Query().Get("o")])
+		return resp, nil
+	})
+
+	cp, err := raCompletionProgress(client, "user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, cp.Total)
+	assert.Len(t, cp.Results, 3)
+	assert.Equal(t, []string{"0", "2"}, offsets)
+}
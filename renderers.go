@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	rendererGowon    = "gowon"
+	rendererMIRC     = "mirc"
+	rendererPlain    = "plain"
+	rendererMarkdown = "markdown"
+	rendererANSI     = "ansi"
+
+	colourClear = "clear"
+)
+
+type renderer struct {
+	Open   func(fg, bg string) string
+	Close  string
+	Span   func(string) string
+	Escape func(string) string
+}
+
+type span struct {
+	Coloured bool
+	Text     string
+}
+
+var mircColours = map[string]int{
+	"white":      0,
+	"black":      1,
+	"blue":       2,
+	"green":      3,
+	"red":        4,
+	"brown":      5,
+	"magenta":    6,
+	"orange":     7,
+	"yellow":     8,
+	"lightgreen": 9,
+	"cyan":       10,
+	"lightcyan":  11,
+	"lightblue":  12,
+	"pink":       13,
+	"grey":       14,
+	"lightgrey":  15,
+}
+
+var ansiColours = map[string]int{
+	"black":      30,
+	"red":        31,
+	"green":      32,
+	"yellow":     33,
+	"blue":       34,
+	"magenta":    35,
+	"cyan":       36,
+	"white":      37,
+	"brown":      33,
+	"orange":     33,
+	"grey":       90,
+	"lightgreen": 92,
+	"lightblue":  94,
+	"pink":       95,
+	"lightcyan":  96,
+	"lightgrey":  97,
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"`", "\\`",
+	"|", `\|`,
+)
+
+var renderers = map[string]renderer{
+	rendererGowon: {
+		Open: func(fg, bg string) string {
+			if bg == "" {
+				return "{" + fg + "}"
+			}
+
+			return "{" + fg + "," + bg + "}"
+		},
+		Close: "{" + colourClear + "}",
+	},
+	rendererMIRC: {
+		Open: func(fg, bg string) string {
+			code, ok := mircColours[fg]
+			if !ok {
+				return ""
+			}
+
+			if n, ok := mircColours[bg]; ok {
+				return fmt.Sprintf("\x03%02d,%02d", code, n)
+			}
+
+			return fmt.Sprintf("\x03%02d", code)
+		},
+		Close: "\x0f",
+	},
+	rendererPlain: {},
+	rendererMarkdown: {
+		Span: func(s string) string {
+			return "**" + s + "**"
+		},
+		Escape: markdownEscaper.Replace,
+	},
+	rendererANSI: {
+		Open: func(fg, bg string) string {
+			code, ok := ansiColours[fg]
+			if !ok {
+				return ""
+			}
+
+			if n, ok := ansiColours[bg]; ok {
+				return fmt.Sprintf("\x1b[%d;%dm", code, n+10)
+			}
+
+			return fmt.Sprintf("\x1b[%dm", code)
+		},
+		Close: "\x1b[0m",
+	},
+}
+
+func findRenderer(name string) (renderer, error) {
+	r, ok := renderers[strings.ToLower(name)]
+	if !ok {
+		return renderer{}, fmt.Errorf("unknown renderer %s, available: %s", name, strings.Join(rendererNames(), ", "))
+	}
+
+	return r, nil
+}
+
+func rendererNames() []string {
+	names := []string{}
+
+	for n := range renderers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (r renderer) Render(s string) string {
+	if r.Span != nil {
+		return r.renderSpans(s)
+	}
+
+	var sb strings.Builder
+
+	text := func(in string) {
+		if r.Escape != nil {
+			in = r.Escape(in)
+		}
+		sb.WriteString(in)
+	}
+
+	last := 0
+
+	for _, m := range colourTag.FindAllStringIndex(s, -1) {
+		text(s[last:m[0]])
+		last = m[1]
+
+		fg, bg, _ := strings.Cut(s[m[0]+1:m[1]-1], ",")
+
+		if fg == colourClear {
+			sb.WriteString(r.Close)
+			continue
+		}
+
+		if r.Open != nil {
+			sb.WriteString(r.Open(fg, bg))
+		}
+	}
+
+	text(s[last:])
+
+	return sb.String()
+}
+
+func splitSpans(s string) []span {
+	spans := []span{}
+	coloured := false
+
+	add := func(text string) {
+		if text == "" {
+			return
+		}
+
+		if n := len(spans); n > 0 && spans[n-1].Coloured == coloured {
+			spans[n-1].Text += text
+			return
+		}
+
+		spans = append(spans, span{coloured, text})
+	}
+
+	last := 0
+
+	for _, m := range colourTag.FindAllStringIndex(s, -1) {
+		add(s[last:m[0]])
+		last = m[1]
+
+		fg, _, _ := strings.Cut(s[m[0]+1:m[1]-1], ",")
+		coloured = fg != colourClear
+	}
+
+	add(s[last:])
+
+	return spans
+}
+
+func (r renderer) renderSpans(s string) string {
+	var sb strings.Builder
+
+	for _, sp := range splitSpans(s) {
+		text := sp.Text
+		if r.Escape != nil {
+			text = r.Escape(text)
+		}
+
+		inner := strings.TrimSpace(text)
+		if !sp.Coloured || inner == "" {
+			sb.WriteString(text)
+			continue
+		}
+
+		lead := text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+		trail := text[len(strings.TrimRightFunc(text, unicode.IsSpace)):]
+
+		sb.WriteString(lead + r.Span(inner) + trail)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRendererRender(t *testing.T) {
+	in := "user | {green}Points: 10{clear} | {yellow,black}Rank: 1/2{clear} | a_b*c"
+
+	achievement := "{magenta}Super Mario World (SNES){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear}"
+
+	cases := map[string]struct {
+		renderer string
+		in       string
+		expected string
+	}{
+		"gowon": {
+			renderer: rendererGowon,
+			in:       in,
+			expected: in,
+		},
+		"mirc": {
+			renderer: rendererMIRC,
+			in:       in,
+			expected: "user | \x0303Points: 10\x0f | \x0308,01Rank: 1/2\x0f | a_b*c",
+		},
+		"plain": {
+			renderer: rendererPlain,
+			in:       in,
+			expected: "user | Points: 10 | Rank: 1/2 | a_b*c",
+		},
+		"markdown": {
+			renderer: rendererMarkdown,
+			in:       in,
+			expected: `user \| **Points: 10** \| **Rank: 1/2** \| a\_b\*c`,
+		},
+		"ansi": {
+			renderer: rendererANSI,
+			in:       in,
+			expected: "user | \x1b[32mPoints: 10\x1b[0m | \x1b[33;40mRank: 1/2\x1b[0m | a_b*c",
+		},
+		"markdown adjacent spans": {
+			renderer: rendererMarkdown,
+			in:       achievement,
+			expected: `**Super Mario World (SNES)** \| **5 points [Hardcore]**`,
+		},
+		"markdown spaces inside span": {
+			renderer: rendererMarkdown,
+			in:       "a{green} b {clear}c",
+			expected: "a **b** c",
+		},
+		"markdown empty span": {
+			renderer: rendererMarkdown,
+			in:       "a{green} {clear}b",
+			expected: "a b",
+		},
+		"markdown unclosed span": {
+			renderer: rendererMarkdown,
+			in:       "a {green}b",
+			expected: "a **b**",
+		},
+		"mirc adjacent spans": {
+			renderer: rendererMIRC,
+			in:       achievement,
+			expected: "\x0306Super Mario World (SNES)\x0f | \x03035 points\x0f\x0308 [Hardcore]\x0f",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := findRenderer(tc.renderer)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, r.Render(tc.in))
+		})
+	}
+}
+
+func TestRendererUnknownColour(t *testing.T) {
+	r, err := findRenderer(rendererMIRC)
+	assert.Nil(t, err)
+	assert.Equal(t, "a\x0f", r.Render("{sparkly}a{clear}"))
+}
+
+func TestFindRendererUnknown(t *testing.T) {
+	_, err := findRenderer("html")
+	assert.EqualError(t, err, "unknown renderer html, available: ansi, gowon, markdown, mirc, plain")
+}
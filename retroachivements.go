@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/imroc/req/v3"
@@ -17,45 +16,46 @@ const (
 	raRecentGamesURL  = raRootURL + "API_GetUserRecentlyPlayedGames.php"
 	raUserSummaryURL  = raRootURL + "API_GetUserSummary.php"
 	raAwardsURL       = raRootURL + "API_GetUserAwards.php"
+	raUserProfileURL  = raRootURL + "API_GetUserProfile.php"
 	raGameProgressURL = raRootURL + "API_GetGameInfoAndUserProgress.php"
-
-	achievementColour       = "cyan"
-	gameColour              = "magenta"
-	pointsColour            = "green"
-	relaxedPointsColour     = "magenta"
-	hardcoreColour          = "yellow"
-	richPresenceColour      = "yellow"
-	rankColour              = "yellow"
-	awardColour             = "yellow"
-	beatenColour            = "red"
-	completedColour         = "cyan"
-	masteredColour          = "yellow"
-	completionPercentColour = "blue"
+	raGameListURL     = raRootURL + "API_GetGameList.php"
+	raConsoleIDsURL   = raRootURL + "API_GetConsoleIDs.php"
+
+	raCompletionProgressURL  = raRootURL + "API_GetUserCompletionProgress.php"
+	raAchievementsOnDayURL   = raRootURL + "API_GetAchievementsEarnedOnDay.php"
+	raAchievementsBetweenURL = raRootURL + "API_GetAchievementsEarnedBetween.php"
+	raUserProgressURL        = raRootURL + "API_GetUserProgress.php"
+
+	achievementColour       = "achievement"
+	gameColour              = "game"
+	pointsColour            = "points"
+	relaxedPointsColour     = "relaxed_points"
+	hardcoreColour          = "hardcore"
+	richPresenceColour      = "rich_presence"
+	rankColour              = "rank"
+	awardColour             = "award"
+	beatenColour            = "beaten"
+	completedColour         = "completed"
+	masteredColour          = "mastered"
+	completionPercentColour = "completion_percent"
+	onlineColour            = "online"
+	offlineColour           = "offline"
+	errorColour             = "error"
+
+	maxLineLength        = 400
+	achievementSeparator = " || "
 )
 
 var (
 	now = time.Now
+
+	colourNames = []string{"green", "red", "blue", "orange", "magenta", "cyan", "yellow"}
 )
 
 func colourString(in, colour string) string {
 	return fmt.Sprintf("{%s}%s{clear}", colour, in)
 }
 
-func colourList(in []string) (out []string) {
-	out = []string{}
-
-	colours := []string{"green", "red", "blue", "orange", "magenta", "cyan", "yellow"}
-	cl := len(colours)
-
-	for n, i := range in {
-		c := colours[n%cl]
-		o := colourString(i, c)
-		out = append(out, o)
-	}
-
-	return out
-}
-
 type Achievement struct {
 	HardcoreMode int    `json:"HardcoreMode"`
 	Title        string `json:"Title"`
@@ -64,39 +64,31 @@ type Achievement struct {
 	GameTitle    string `json:"GameTitle"`
 	ConsoleName  string `json:"ConsoleName"`
 	GameID       int    `json:"GameID"`
+	Date         raTime `json:"Date"`
 }
 
-func formatAchievement(a Achievement) string {
-	var sb strings.Builder
-
-	w := func(in, colour string) {
-		s := colourString(in, colour)
-		sb.WriteString(s)
-	}
-
-	w(fmt.Sprintf("%s (%s)", a.Title, strings.TrimRight(a.Description, ".")), achievementColour)
-
-	sb.WriteString(" | ")
-
-	w(fmt.Sprintf("%s (%s)", a.GameTitle, a.ConsoleName), gameColour)
-
-	sb.WriteString(" | ")
+func formatAchievement(a Achievement, p Preferences) (string, error) {
+	return renderTemplate("achievement", p, a)
+}
 
-	w(fmt.Sprintf("%d points", a.Points), pointsColour)
+func hardcoreAchievements(achievements []Achievement) []Achievement {
+	out := []Achievement{}
 
-	if a.HardcoreMode == 1 {
-		w(" [Hardcore]", hardcoreColour)
+	for _, a := range achievements {
+		if a.HardcoreMode == 1 {
+			out = append(out, a)
+		}
 	}
 
-	return sb.String()
+	return out
 }
 
-func raNewestAchievement(client *req.Client, user string) (string, error) {
+func raNewestAchievement(client *req.Client, user string, count int, since time.Duration, p Preferences) (string, error) {
 	var j []Achievement
 
 	_, err := client.R().
 		SetQueryParam("u", user).
-		SetQueryParam("m", "43200").
+		SetQueryParam("m", sinceMinutes(since)).
 		SetSuccessResult(&j).
 		Get(raAchievementsURL)
 
@@ -104,26 +96,36 @@ func raNewestAchievement(client *req.Client, user string) (string, error) {
 		return "", err
 	}
 
+	if p.HideSoftcore() {
+		j = hardcoreAchievements(j)
+	}
+
 	if len(j) == 0 {
-		return fmt.Sprintf("No recent achievements found for user %s", user), nil
+		return p.Locale.Sprintf("No recent achievements found for user %s", user), nil
 	}
 
-	a := formatAchievement(j[0])
-	out := fmt.Sprintf("%s's newest retroachievement: %s", user, a)
+	if len(j) > count {
+		j = j[:count]
+	}
 
-	return out, nil
+	return renderTemplate("newest", p, struct {
+		User         string
+		Count        int
+		Achievements []Achievement
+	}{user, count, j})
 }
 
 type Game struct {
-	Title string `json:"Title"`
+	Title      string `json:"Title"`
+	LastPlayed raTime `json:"LastPlayed"`
 }
 
-func raLastGames(client *req.Client, user string) (string, error) {
+func raLastGames(client *req.Client, user string, count int, p Preferences) (string, error) {
 	var j []Game
 
 	_, err := client.R().
 		SetQueryParam("u", user).
-		SetQueryParam("c", "10").
+		SetQueryParam("c", strconv.Itoa(count)).
 		SetSuccessResult(&j).
 		Get(raRecentGamesURL)
 
@@ -132,17 +134,34 @@ func raLastGames(client *req.Client, user string) (string, error) {
 	}
 
 	if len(j) == 0 {
-		return fmt.Sprintf("No played games found for user %s", user), nil
+		return p.Locale.Sprintf("No played games found for user %s", user), nil
 	}
 
-	titles := []string{}
-	for _, g := range j {
-		titles = append(titles, g.Title)
+	if len(j) > count {
+		j = j[:count]
 	}
 
-	cl := strings.Join(colourList(titles), ", ")
+	return renderTemplate("last", p, struct {
+		User  string
+		Games []Game
+	}{user, j})
+}
 
-	return fmt.Sprintf("%s's last played retro games: %s", user, cl), nil
+type UserProfile struct {
+	User  string `json:"User"`
+	ID    int    `json:"ID"`
+	Motto string `json:"Motto"`
+}
+
+func raUserProfile(client *req.Client, user string) (UserProfile, error) {
+	var j UserProfile
+
+	_, err := client.R().
+		SetQueryParam("u", user).
+		SetSuccessResult(&j).
+		Get(raUserProfileURL)
+
+	return j, err
 }
 
 type UserSummary struct {
@@ -150,7 +169,7 @@ type UserSummary struct {
 	Status         string `json:"Status"`
 	RecentlyPlayed []struct {
 		Title      string `json:"Title"`
-		LastPlayed string `json:"LastPlayed"`
+		LastPlayed raTime `json:"LastPlayed"`
 	} `json:"RecentlyPlayed"`
 	RichPresenceMsg     string `json:"RichPresenceMsg"`
 	TotalPoints         int    `json:"TotalPoints"`
@@ -165,12 +184,10 @@ func (us *UserSummary) IsOnline() bool {
 		return false
 	}
 
-	t, _ := time.Parse(timeDateFormat, us.RecentlyPlayed[0].LastPlayed)
-
-	return now().Unix() < t.Unix()+180
+	return now().Unix() < us.RecentlyPlayed[0].LastPlayed.Unix()+180
 }
 
-func raCurrentStatus(client *req.Client, user string) (string, error) {
+func raCurrentStatus(client *req.Client, user string, p Preferences) (string, error) {
 	var j UserSummary
 
 	_, err := client.R().
@@ -185,37 +202,30 @@ func raCurrentStatus(client *req.Client, user string) (string, error) {
 	}
 
 	if j.ID == 0 {
-		return fmt.Sprintf("User %s not found", user), nil
+		return p.Locale.Sprintf("User %s not found", user), nil
 	}
 
-	var sb strings.Builder
+	current := struct {
+		User         string
+		Online       bool
+		Game         string
+		RichPresence string
+		LastSeen     time.Time
+	}{User: user, Online: j.IsOnline()}
 
-	w := func(in, colour string) {
-		s := colourString(in, colour)
-		sb.WriteString(s)
+	if len(j.RecentlyPlayed) > 0 {
+		current.LastSeen = j.RecentlyPlayed[0].LastPlayed.Time
 	}
 
-	sb.WriteString(fmt.Sprintf("%s | ", user))
-
-	if !j.IsOnline() {
-		w("Offline", "red")
-		return sb.String(), nil
+	if current.Online {
+		current.Game = j.RecentlyPlayed[0].Title
+		current.RichPresence = j.RichPresenceMsg
 	}
 
-	w("Online", "green")
-
-	sb.WriteString(" | ")
-
-	w(j.RecentlyPlayed[0].Title, gameColour)
-
-	sb.WriteString(" | ")
-
-	w(j.RichPresenceMsg, richPresenceColour)
-
-	return sb.String(), nil
+	return renderTemplate("current", p, current)
 }
 
-func raPoints(client *req.Client, user string) (string, error) {
+func raPoints(client *req.Client, user string, p Preferences) (string, error) {
 	var j UserSummary
 
 	_, err := client.R().
@@ -228,39 +238,43 @@ func raPoints(client *req.Client, user string) (string, error) {
 	}
 
 	if j.ID == 0 {
-		return fmt.Sprintf("User %s not found", user), nil
-	}
-
-	var sb strings.Builder
-
-	w := func(in, colour string) {
-		s := colourString(in, colour)
-		sb.WriteString(s)
+		return p.Locale.Sprintf("User %s not found", user), nil
 	}
 
-	sb.WriteString(fmt.Sprintf("%s | ", user))
-
-	w(fmt.Sprintf("Points: %d (%d)", j.TotalPoints, j.TotalTruePoints), pointsColour)
-
-	sb.WriteString(" | ")
+	return renderTemplate("points", p, struct {
+		User string
+		UserSummary
+	}{user, j})
+}
 
-	w(fmt.Sprintf("Relaxed: %d", j.TotalSoftcorePoints), relaxedPointsColour)
+type Awards struct {
+	BeatenHardcore int         `json:"BeatenHardcoreAwardsCount"`
+	BeatenSoftcore int         `json:"BeatenSoftcoreAwardsCount"`
+	Completed      int         `json:"CompletionAwardsCount"`
+	Mastered       int         `json:"MasteryAwardsCount"`
+	Visible        []UserAward `json:"VisibleUserAwards"`
+}
 
-	sb.WriteString(" | ")
+type UserAward struct {
+	Title       string `json:"Title"`
+	AwardType   string `json:"AwardType"`
+	ConsoleName string `json:"ConsoleName"`
+	AwardedAt   raTime `json:"AwardedAt"`
+}
 
-	w(fmt.Sprintf("Rank: %d/%d", j.Rank, j.TotalRanked), rankColour)
+func (a Awards) Latest() *UserAward {
+	var latest *UserAward
 
-	return sb.String(), nil
-}
+	for i, ua := range a.Visible {
+		if latest == nil || ua.AwardedAt.After(latest.AwardedAt.Time) {
+			latest = &a.Visible[i]
+		}
+	}
 
-type Awards struct {
-	BeatenHardcore int `json:"BeatenHardcoreAwardsCount"`
-	BeatenSoftcore int `json:"BeatenSoftcoreAwardsCount"`
-	Completed      int `json:"CompletionAwardsCount"`
-	Mastered       int `json:"MasteryAwardsCount"`
+	return latest
 }
 
-func raAwards(client *req.Client, user string) (string, error) {
+func raAwards(client *req.Client, user string, p Preferences) (string, error) {
 	var j Awards
 
 	_, err := client.R().
@@ -272,26 +286,17 @@ func raAwards(client *req.Client, user string) (string, error) {
 		return "", err
 	}
 
-	var sb strings.Builder
-
-	w := func(in, colour string) {
-		s := colourString(in, colour)
-		sb.WriteString(s)
-	}
-
-	sb.WriteString(fmt.Sprintf("%s | ", user))
-
-	w(fmt.Sprintf("Beaten: %d (Relaxed: %d)", j.BeatenHardcore, j.BeatenSoftcore), beatenColour)
-
-	sb.WriteString(" | ")
-
-	w(fmt.Sprintf("Completed: %d", j.Completed), completedColour)
-
-	sb.WriteString(" | ")
-
-	w(fmt.Sprintf("Mastered: %d", j.Mastered), masteredColour)
+	return renderTemplate("awards", p, struct {
+		User string
+		Awards
+	}{user, j})
+}
 
-	return sb.String(), nil
+var highestAwards = map[string]string{
+	"beaten-softcore": "Beaten",
+	"beaten-hardcore": "Beaten [Hardcore]",
+	"completed":       "Completed",
+	"mastered":        "Mastered",
 }
 
 type GameProgress struct {
@@ -304,33 +309,41 @@ type GameProgress struct {
 	AchievementsHardcore int    `json:"NumAwardedToUserHardcore"`
 	Achievements         map[string]struct {
 		Points     int    `json:"Points"`
-		DateEarned string `json:"DateEarned"`
+		DateEarned raTime `json:"DateEarned"`
 	} `json:"Achievements"`
-	PointsTotal  int    `json:"points_total"`
-	HighestAward string `json:"HighestAwardKind"`
+	PointsTotal      int    `json:"points_total"`
+	HighestAward     string `json:"HighestAwardKind"`
+	HighestAwardDate raTime `json:"HighestAwardDate"`
 }
 
-func (gp *GameProgress) PointsAwarded() string {
+func (gp GameProgress) PointsAwarded() int {
 	points := 0
-	pointsAwarded := 0
 
 	for _, a := range gp.Achievements {
-		points += a.Points
-
-		if a.DateEarned != "" {
-			pointsAwarded += a.Points
+		if !a.DateEarned.IsZero() {
+			points += a.Points
 		}
 	}
 
-	return fmt.Sprintf("%d/%d", pointsAwarded, points)
+	return points
+}
+
+func (gp GameProgress) PointsPossible() int {
+	points := 0
+
+	for _, a := range gp.Achievements {
+		points += a.Points
+	}
+
+	return points
 }
 
-func raGameProgress(client *req.Client, user string) (string, error) {
+func raGameProgress(client *req.Client, user string, since time.Duration, p Preferences) (string, error) {
 	var aj []Achievement
 
 	_, err := client.R().
 		SetQueryParam("u", user).
-		SetQueryParam("m", "43200").
+		SetQueryParam("m", sinceMinutes(since)).
 		SetSuccessResult(&aj).
 		Get(raAchievementsURL)
 
@@ -339,7 +352,7 @@ func raGameProgress(client *req.Client, user string) (string, error) {
 	}
 
 	if len(aj) == 0 {
-		return fmt.Sprintf("No recent played games found for user %s", user), nil
+		return p.Locale.Sprintf("No recent played games found for user %s", user), nil
 	}
 
 	gameID := strconv.Itoa(aj[0].GameID)
@@ -357,54 +370,9 @@ func raGameProgress(client *req.Client, user string) (string, error) {
 		return "", err
 	}
 
-	var sb strings.Builder
-
-	w := func(in, colour string) {
-		s := colourString(in, colour)
-		sb.WriteString(s)
-	}
-
-	sb.WriteString(fmt.Sprintf("%s | ", user))
-
-	w(fmt.Sprintf("%s (%s)", gj.Title, gj.Console), gameColour)
-
-	sb.WriteString(" | ")
-
-	var cb strings.Builder
-	cb.WriteString(fmt.Sprintf("Completion: %s", gj.CompletionHardcore))
-
-	if gj.CompletionHardcore != gj.Completion {
-		cb.WriteString(fmt.Sprintf(" (Relaxed: %s)", gj.Completion))
-	}
-
-	w(cb.String(), completionPercentColour)
-
-	sb.WriteString(" | ")
-
-	var ab strings.Builder
-	ab.WriteString(fmt.Sprintf("Achievements: %d/%d", gj.AchievementsHardcore, gj.NumAchievements))
-
-	if gj.AchievementsHardcore != gj.AchievementsRelaxed {
-		ab.WriteString(fmt.Sprintf(" (Relaxed: %d)", gj.AchievementsRelaxed))
-	}
-
-	w(ab.String(), achievementColour)
-
-	sb.WriteString(" | ")
-
-	w(fmt.Sprintf("Points: %s", gj.PointsAwarded()), pointsColour)
-
-	if gj.HighestAward != "" {
-		awards := map[string]string{
-			"beaten-softcore": "Beaten",
-			"beaten-hardcore": "Beaten [Hardcore]",
-			"completed":       "Completed",
-			"mastered":        "Mastered",
-		}
-
-		sb.WriteString(" | ")
-		w(awards[gj.HighestAward], awardColour)
-	}
-
-	return sb.String(), nil
+	return renderTemplate("progress", p, struct {
+		User string
+		GameProgress
+		Award string
+	}{user, gj, highestAwards[gj.HighestAward]})
 }
@@ -24,32 +24,14 @@ func openTestFile(t *testing.T, endpoint, filename string) []byte {
 	return out
 }
 
-func TestColourList(t *testing.T) {
-	cases := map[string]struct {
-		in       []string
-		expected []string
-	}{
-		"empty list": {
-			in:       []string{},
-			expected: []string{},
-		},
-		"single item": {
-			in:       []string{"a"},
-			expected: []string{"{green}a{clear}"},
-		},
-		"two items": {
-			in:       []string{"a", "b"},
-			expected: []string{"{green}a{clear}", "{red}b{clear}"},
-		},
+func fixNow(t *testing.T, value string) {
+	n, err := time.Parse(timeDateFormat, value)
+	if err != nil {
+		t.Fatalf("failed to parse test time: %s", err)
 	}
 
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			out := colourList(tc.in)
-
-			assert.Equal(t, tc.expected, out)
-		})
-	}
+	now = func() time.Time { return n }
+	t.Cleanup(func() { now = time.Now })
 }
 
 func TestFormatAchievement(t *testing.T) {
@@ -83,32 +65,45 @@ func TestFormatAchievement(t *testing.T) {
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			out := formatAchievement(tc.in)
+			out, err := formatAchievement(tc.in, defaultPreferences())
 
+			assert.Nil(t, err)
 			assert.Equal(t, tc.expected, out)
 		})
 	}
 }
 
 func TestRaNewestAchievement(t *testing.T) {
+	fixNow(t, "2024-08-31 17:00:00")
+
 	cases := map[string]struct {
 		jsonfn   string
+		count    int
 		expected string
 		err      error
 	}{
 		"no achievements": {
 			jsonfn:   "no_achievements.json",
+			count:    1,
 			expected: "No recent achievements found for user user",
 			err:      nil,
 		},
 		"one achievement": {
 			jsonfn:   "one_achievement.json",
-			expected: "user's newest retroachievement: {cyan}title 1 (description 1){clear} | {magenta}game 1 (console 1){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear}",
+			count:    1,
+			expected: "user's newest retroachievement: {cyan}title 1 (description 1){clear} | {magenta}game 1 (console 1){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear} | 2d ago",
 			err:      nil,
 		},
 		"many achievements": {
 			jsonfn:   "many_achievements.json",
-			expected: "user's newest retroachievement: {cyan}title 1 (description 1){clear} | {magenta}game 1 (console 1){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear}",
+			count:    1,
+			expected: "user's newest retroachievement: {cyan}title 1 (description 1){clear} | {magenta}game 1 (console 1){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear} | 2d ago",
+			err:      nil,
+		},
+		"many achievements with count": {
+			jsonfn:   "many_achievements.json",
+			count:    2,
+			expected: "user's newest retroachievements: {cyan}title 1 (description 1){clear} | {magenta}game 1 (console 1){clear} | {green}5 points{clear}{yellow} [Hardcore]{clear} | 2d ago || {cyan}title 2 (description 2){clear} | {magenta}game 2 (console 2){clear} | {green}10 points{clear}{yellow} [Hardcore]{clear} | 2d ago",
 			err:      nil,
 		},
 	}
@@ -124,7 +119,7 @@ func TestRaNewestAchievement(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raNewestAchievement(client, "user")
+			out, err := raNewestAchievement(client, "user", tc.count, defaultSince, defaultPreferences())
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -133,24 +128,36 @@ func TestRaNewestAchievement(t *testing.T) {
 }
 
 func TestRaRecentGames(t *testing.T) {
+	fixNow(t, "2024-08-31 17:00:00")
+
 	cases := map[string]struct {
 		jsonfn   string
+		count    int
 		expected string
 		err      error
 	}{
 		"no games": {
 			jsonfn:   "no_games.json",
+			count:    10,
 			expected: "No played games found for user user",
 			err:      nil,
 		},
 		"one game": {
 			jsonfn:   "one_game.json",
-			expected: "user's last played retro games: {green}Game 1{clear}",
+			count:    10,
+			expected: "user's last played retro games: {green}Game 1 (2d ago){clear}",
 			err:      nil,
 		},
 		"many games": {
 			jsonfn:   "many_games.json",
-			expected: "user's last played retro games: {green}Game 1{clear}, {red}Game 2{clear}, {blue}Game 3{clear}",
+			count:    10,
+			expected: "user's last played retro games: {green}Game 1 (2d ago){clear}, {red}Game 2 (2d ago){clear}, {blue}Game 3 (2d ago){clear}",
+			err:      nil,
+		},
+		"many games with count": {
+			jsonfn:   "many_games.json",
+			count:    2,
+			expected: "user's last played retro games: {green}Game 1 (2d ago){clear}, {red}Game 2 (2d ago){clear}",
 			err:      nil,
 		},
 	}
@@ -166,7 +173,7 @@ func TestRaRecentGames(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raLastGames(client, "user")
+			out, err := raLastGames(client, "user", tc.count, defaultPreferences())
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -222,7 +229,7 @@ func TestRaCurrentStatus(t *testing.T) {
 		"offline": {
 			jsonfn:   "summary.json",
 			now:      "2024-08-31 17:04:00",
-			expected: "user | {red}Offline{clear}",
+			expected: "user | {red}Offline{clear} | Last seen 4m ago",
 			err:      nil,
 		},
 	}
@@ -239,7 +246,7 @@ func TestRaCurrentStatus(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raCurrentStatus(client, "user")
+			out, err := raCurrentStatus(client, "user", defaultPreferences())
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -250,12 +257,32 @@ func TestRaCurrentStatus(t *testing.T) {
 func TestRaPoints(t *testing.T) {
 	cases := map[string]struct {
 		jsonfn   string
+		prefs    Preferences
 		expected string
 		err      error
 	}{
 		"points": {
 			jsonfn:   "summary.json",
-			expected: "user | {green}Points: 509 (1084){clear} | {magenta}Relaxed: 2376{clear} | {yellow}Rank: 51006/70476{clear}",
+			prefs:    defaultPreferences(),
+			expected: "user | {green}Points: 509 (1,084){clear} | {magenta}Relaxed: 2,376{clear} | {yellow}Rank: 51,006/70,476{clear}",
+			err:      nil,
+		},
+		"short": {
+			jsonfn:   "summary.json",
+			prefs:    Preferences{Detail: detailShort},
+			expected: "user | {green}Points: 509 (1,084){clear}",
+			err:      nil,
+		},
+		"hide softcore": {
+			jsonfn:   "summary.json",
+			prefs:    Preferences{Softcore: softcoreHide},
+			expected: "user | {green}Points: 509 (1,084){clear} | {yellow}Rank: 51,006/70,476{clear}",
+			err:      nil,
+		},
+		"german": {
+			jsonfn:   "summary.json",
+			prefs:    parsePreferences(map[string]string{prefLanguage: "de"}),
+			expected: "user | {green}Punkte: 509 (1.084){clear} | {magenta}Entspannt: 2.376{clear} | {yellow}Rang: 51.006/70.476{clear}",
 			err:      nil,
 		},
 	}
@@ -271,7 +298,7 @@ func TestRaPoints(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raPoints(client, "user")
+			out, err := raPoints(client, "user", tc.prefs)
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -280,14 +307,24 @@ func TestRaPoints(t *testing.T) {
 }
 
 func TestRaAwards(t *testing.T) {
+	fixNow(t, "2024-08-31 17:00:00")
+
 	cases := map[string]struct {
 		jsonfn   string
+		prefs    Preferences
 		expected string
 		err      error
 	}{
 		"awards": {
 			jsonfn:   "awards.json",
-			expected: "user | {red}Beaten: 1 (Relaxed: 5){clear} | {cyan}Completed: 3{clear} | {yellow}Mastered: 0{clear}",
+			prefs:    defaultPreferences(),
+			expected: "user | {red}Beaten: 1 (Relaxed: 5){clear} | {cyan}Completed: 3{clear} | {yellow}Mastered: 0{clear} | Latest: ~Hack~ Pokemon Emerald Rogue (340d ago)",
+			err:      nil,
+		},
+		"hide softcore": {
+			jsonfn:   "awards.json",
+			prefs:    Preferences{Softcore: softcoreHide},
+			expected: "user | {red}Beaten: 1{clear} | {yellow}Mastered: 0{clear} | Latest: ~Hack~ Pokemon Emerald Rogue (340d ago)",
 			err:      nil,
 		},
 	}
@@ -303,7 +340,7 @@ func TestRaAwards(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raAwards(client, "user")
+			out, err := raAwards(client, "user", tc.prefs)
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -314,11 +351,13 @@ func TestRaAwards(t *testing.T) {
 func TestGameProgressPointsAwarded(t *testing.T) {
 	cases := map[string]struct {
 		jsonfn   string
-		expected string
+		awarded  int
+		possible int
 	}{
 		"points": {
 			jsonfn:   "progress.json",
-			expected: "419/1369",
+			awarded:  419,
+			possible: 1369,
 		},
 	}
 
@@ -329,12 +368,15 @@ func TestGameProgressPointsAwarded(t *testing.T) {
 			err := json.Unmarshal(j, &gp)
 			assert.Nil(t, err)
 
-			assert.Equal(t, tc.expected, gp.PointsAwarded())
+			assert.Equal(t, tc.awarded, gp.PointsAwarded())
+			assert.Equal(t, tc.possible, gp.PointsPossible())
 		})
 	}
 }
 
 func TestRaGameProgress(t *testing.T) {
+	fixNow(t, "2024-08-31 17:00:00")
+
 	cases := map[string]struct {
 		jsonfn   string
 		expected string
@@ -342,7 +384,7 @@ func TestRaGameProgress(t *testing.T) {
 	}{
 		"progress": {
 			jsonfn:   "progress.json",
-			expected: "user | {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {blue}Completion: 0.64% (Relaxed: 33.12%){clear} | {cyan}Achievements: 1/157 (Relaxed: 52){clear} | {green}Points: 419/1369{clear} | {yellow}Completed{clear}",
+			expected: "user | {magenta}~Hack~ Pokemon Radical Red (Game Boy Advance){clear} | {blue}Completion: 0.64% (Relaxed: 33.12%){clear} | {cyan}Achievements: 1/157 (Relaxed: 52){clear} | {green}Points: 419/1,369{clear} | {yellow}Completed{clear} (678d ago)",
 			err:      nil,
 		},
 	}
@@ -365,7 +407,7 @@ func TestRaGameProgress(t *testing.T) {
 				return resp, nil
 			})
 
-			out, err := raGameProgress(client, "user")
+			out, err := raGameProgress(client, "user", defaultSince, defaultPreferences())
 
 			assert.Equal(t, tc.expected, out)
 			assert.ErrorIs(t, tc.err, err)
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	metaBucket        = []byte("meta")
+	usersBucket       = []byte("users")
+	legacyUsersBucket = []byte("retroachievements")
+
+	schemaVersionKey = []byte("schema_version")
+)
+
+type migration struct {
+	Description string
+	Migrate     func(tx *bolt.Tx) error
+}
+
+var migrations = []migration{
+	{
+		Description: "convert user links to json user records",
+		Migrate:     migrateUserRecords,
+	},
+}
+
+func schemaVersion(tx *bolt.Tx) (int, error) {
+	b := tx.Bucket(metaBucket)
+	if b == nil {
+		return 0, nil
+	}
+
+	v := b.Get(schemaVersionKey)
+	if v == nil {
+		return 0, nil
+	}
+
+	return strconv.Atoi(string(v))
+}
+
+func setSchemaVersion(tx *bolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(schemaVersionKey, []byte(strconv.Itoa(version)))
+}
+
+func isEmpty(tx *bolt.Tx) bool {
+	empty := true
+
+	_ = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		empty = false
+		return nil
+	})
+
+	return empty
+}
+
+func runMigrations(kv *bolt.DB, backupPath string) error {
+	var version int
+	var empty bool
+
+	err := kv.View(func(tx *bolt.Tx) (err error) {
+		version, err = schemaVersion(tx)
+		empty = isEmpty(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	if version == len(migrations) {
+		return nil
+	}
+
+	if !empty {
+		backup := fmt.Sprintf("%s.v%d.bak", backupPath, version)
+
+		err = kv.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(backup, 0600)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to back up database before migrating: %w", err)
+		}
+
+		log.Printf("backed up database to %s\n", backup)
+	}
+
+	for n, m := range migrations[version:] {
+		v := version + n + 1
+
+		err = kv.Update(func(tx *bolt.Tx) error {
+			if err := m.Migrate(tx); err != nil {
+				return err
+			}
+
+			return setSchemaVersion(tx, v)
+		})
+		if err != nil {
+			return fmt.Errorf("migration to schema version %d failed: %w", v, err)
+		}
+
+		log.Printf("migrated database to schema version %d: %s\n", v, m.Description)
+	}
+
+	return nil
+}
+
+func migrateUserRecords(tx *bolt.Tx) error {
+	users, err := tx.CreateBucketIfNotExists(usersBucket)
+	if err != nil {
+		return err
+	}
+
+	legacy := tx.Bucket(legacyUsersBucket)
+	if legacy == nil {
+		return nil
+	}
+
+	err = legacy.ForEach(func(k, v []byte) error {
+		if users.Get(k) != nil {
+			return nil
+		}
+
+		rec, err := json.Marshal(userRecord{User: string(v)})
+		if err != nil {
+			return err
+		}
+
+		return users.Put(k, rec)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.DeleteBucket(legacyUsersBucket)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/assert"
+)
+
+func openEmptyTestDB(t *testing.T) (*bolt.DB, string) {
+	path := filepath.Join(t.TempDir(), "kv.db")
+
+	kv, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("failed to open test db: %s", err)
+	}
+	t.Cleanup(func() { kv.Close() })
+
+	return kv, path
+}
+
+func TestRunMigrationsLegacy(t *testing.T) {
+	kv, path := openEmptyTestDB(t)
+
+	err := kv.Update(func(tx *bolt.Tx) error {
+		legacy, err := tx.CreateBucket([]byte("retroachievements"))
+		if err != nil {
+			return err
+		}
+
+		for k, v := range map[string]string{"alice": "Alice", "bob": "Bob", "carol": "OldCarol"} {
+			if err := legacy.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+
+		users, err := tx.CreateBucket([]byte("users"))
+		if err != nil {
+			return err
+		}
+
+		return users.Put([]byte("carol"), []byte(`{"user":"Carol","id":3,"verified_id":3}`))
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, runMigrations(kv, path))
+
+	_, err = os.Stat(path + ".v0.bak")
+	assert.Nil(t, err)
+
+	store := &boltStore{db: kv}
+
+	cases := map[string]struct {
+		nick     string
+		expected userRecord
+	}{
+		"legacy link": {
+			nick:     "alice",
+			expected: userRecord{User: "Alice"},
+		},
+		"another legacy link": {
+			nick:     "bob",
+			expected: userRecord{User: "Bob"},
+		},
+		"existing record": {
+			nick:     "carol",
+			expected: userRecord{User: "Carol", ID: 3, VerifiedID: 3},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec, err := store.GetUser(tc.nick)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, rec)
+		})
+	}
+
+	err = kv.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte("retroachievements")))
+
+		version, err := schemaVersion(tx)
+		assert.Equal(t, len(migrations), version)
+		return err
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, runMigrations(kv, path))
+}
+
+func TestRunMigrationsFresh(t *testing.T) {
+	kv, path := openEmptyTestDB(t)
+
+	assert.Nil(t, runMigrations(kv, path))
+
+	_, err := os.Stat(path + ".v0.bak")
+	assert.True(t, os.IsNotExist(err))
+
+	err = kv.View(func(tx *bolt.Tx) error {
+		version, err := schemaVersion(tx)
+		assert.Equal(t, len(migrations), version)
+		return err
+	})
+	assert.Nil(t, err)
+}
+
+func TestRunMigrationsNewerVersion(t *testing.T) {
+	kv, path := openEmptyTestDB(t)
+
+	err := kv.Update(func(tx *bolt.Tx) error {
+		return setSchemaVersion(tx, len(migrations)+1)
+	})
+	assert.Nil(t, err)
+
+	assert.Error(t, runMigrations(kv, path))
+}
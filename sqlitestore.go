@@ -0,0 +1,337 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	nick        TEXT PRIMARY KEY,
+	user        TEXT NOT NULL DEFAULT '',
+	ra_id       INTEGER NOT NULL DEFAULT 0,
+	token       TEXT NOT NULL DEFAULT '',
+	verified_id INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS preferences (
+	nick  TEXT NOT NULL,
+	key   TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (nick, key)
+);
+
+CREATE TABLE IF NOT EXISTS channel_settings (
+	channel TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	value   TEXT NOT NULL,
+	PRIMARY KEY (channel, key)
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	nick    TEXT NOT NULL,
+	time    INTEGER NOT NULL,
+	command TEXT NOT NULL,
+	args    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS history_nick ON history (nick, id);
+
+CREATE TABLE IF NOT EXISTS cursors (
+	name  TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) GetUser(nick string) (rec userRecord, err error) {
+	err = s.db.QueryRow(
+		"SELECT user, ra_id, token, verified_id FROM users WHERE nick = ?", nick,
+	).Scan(&rec.User, &rec.ID, &rec.Token, &rec.VerifiedID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return userRecord{}, nil
+	}
+
+	return rec, err
+}
+
+func (s *sqliteStore) UpdateUser(nick string, f func(*userRecord)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rec := userRecord{}
+
+	err = tx.QueryRow(
+		"SELECT user, ra_id, token, verified_id FROM users WHERE nick = ?", nick,
+	).Scan(&rec.User, &rec.ID, &rec.Token, &rec.VerifiedID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	f(&rec)
+
+	_, err = tx.Exec(
+		`INSERT INTO users (nick, user, ra_id, token, verified_id) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (nick) DO UPDATE SET user = excluded.user, ra_id = excluded.ra_id, token = excluded.token, verified_id = excluded.verified_id`,
+		nick, rec.User, rec.ID, rec.Token, rec.VerifiedID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) DeleteUser(nick string) error {
+	_, err := s.db.Exec("DELETE FROM users WHERE nick = ?", nick)
+	return err
+}
+
+func (s *sqliteStore) queryMap(query string, args ...any) (map[string]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]string{}
+
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []string{}
+
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) Preferences(nick string) (map[string]string, error) {
+	return s.queryMap("SELECT key, value FROM preferences WHERE nick = ?", nick)
+}
+
+func (s *sqliteStore) SetPreference(nick, key, value string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO preferences (nick, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (nick, key) DO UPDATE SET value = excluded.value`,
+		nick, key, value,
+	)
+	return err
+}
+
+func (s *sqliteStore) DeletePreference(nick, key string) error {
+	_, err := s.db.Exec("DELETE FROM preferences WHERE nick = ? AND key = ?", nick, key)
+	return err
+}
+
+func (s *sqliteStore) Channels() ([]string, error) {
+	return s.queryStrings("SELECT DISTINCT channel FROM channel_settings ORDER BY channel")
+}
+
+func (s *sqliteStore) ChannelSettings(channel string) (map[string]string, error) {
+	return s.queryMap("SELECT key, value FROM channel_settings WHERE channel = ?", channel)
+}
+
+func (s *sqliteStore) SetChannelSetting(channel, key, value string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO channel_settings (channel, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (channel, key) DO UPDATE SET value = excluded.value`,
+		channel, key, value,
+	)
+	return err
+}
+
+func (s *sqliteStore) DeleteChannelSetting(channel, key string) error {
+	_, err := s.db.Exec("DELETE FROM channel_settings WHERE channel = ? AND key = ?", channel, key)
+	return err
+}
+
+func (s *sqliteStore) AddHistory(nick string, e historyEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO history (nick, time, command, args) VALUES (?, ?, ?, ?)",
+		nick, e.Time.UnixNano(), e.Command, e.Args,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`DELETE FROM history WHERE nick = ? AND id NOT IN (
+			SELECT id FROM history WHERE nick = ? ORDER BY id DESC LIMIT ?
+		)`,
+		nick, nick, maxHistoryEntries,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) History(nick string) ([]historyEntry, error) {
+	rows, err := s.db.Query("SELECT time, command, args FROM history WHERE nick = ? ORDER BY id", nick)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	h := []historyEntry{}
+
+	for rows.Next() {
+		var t int64
+		e := historyEntry{}
+
+		if err := rows.Scan(&t, &e.Command, &e.Args); err != nil {
+			return nil, err
+		}
+
+		e.Time = time.Unix(0, t).UTC()
+		h = append(h, e)
+	}
+
+	return h, rows.Err()
+}
+
+func (s *sqliteStore) Cursor(name string) (value string, err error) {
+	err = s.db.QueryRow("SELECT value FROM cursors WHERE name = ?", name).Scan(&value)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return value, err
+}
+
+func (s *sqliteStore) SetCursor(name, value string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO cursors (name, value) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET value = excluded.value`,
+		name, value,
+	)
+	return err
+}
+
+func (s *sqliteStore) Cursors() (map[string]string, error) {
+	return s.queryMap("SELECT name, value FROM cursors")
+}
+
+func (s *sqliteStore) Nicks() ([]string, error) {
+	return s.queryStrings(`
+		SELECT nick FROM users
+		UNION SELECT nick FROM preferences
+		UNION SELECT nick FROM history
+		ORDER BY nick`)
+}
+
+func (s *sqliteStore) RenameNick(from, to string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"UPDATE OR IGNORE users SET nick = ? WHERE nick = ?",
+		"DELETE FROM users WHERE nick = ?",
+		"UPDATE preferences SET nick = ? WHERE nick = ? AND NOT EXISTS (SELECT 1 FROM preferences WHERE nick = ?)",
+		"DELETE FROM preferences WHERE nick = ?",
+		"UPDATE history SET nick = ? WHERE nick = ? AND NOT EXISTS (SELECT 1 FROM history WHERE nick = ?)",
+		"DELETE FROM history WHERE nick = ?",
+	}
+
+	args := [][]any{
+		{to, from},
+		{from},
+		{to, from, to},
+		{from},
+		{to, from, to},
+		{from},
+	}
+
+	for n, stmt := range statements {
+		if _, err := tx.Exec(stmt, args[n]...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Clear() error {
+	_, err := s.db.Exec(`
+		DELETE FROM users;
+		DELETE FROM preferences;
+		DELETE FROM channel_settings;
+		DELETE FROM history;
+		DELETE FROM cursors;`)
+	return err
+}
+
+func (s *sqliteStore) Backup(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	_, err := s.db.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const maxHistoryEntries = 50
+
+type userRecord struct {
+	User       string `json:"user"`
+	ID         int    `json:"id,omitempty"`
+	Token      string `json:"token,omitempty"`
+	VerifiedID int    `json:"verified_id,omitempty"`
+}
+
+func (r userRecord) Verified() bool {
+	return r.ID != 0 && r.VerifiedID == r.ID
+}
+
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Args    string    `json:"args,omitempty"`
+}
+
+type Store interface {
+	GetUser(nick string) (userRecord, error)
+	UpdateUser(nick string, f func(*userRecord)) error
+	DeleteUser(nick string) error
+
+	Preferences(nick string) (map[string]string, error)
+	SetPreference(nick, key, value string) error
+	DeletePreference(nick, key string) error
+
+	Channels() ([]string, error)
+	ChannelSettings(channel string) (map[string]string, error)
+	SetChannelSetting(channel, key, value string) error
+	DeleteChannelSetting(channel, key string) error
+
+	AddHistory(nick string, e historyEntry) error
+	History(nick string) ([]historyEntry, error)
+
+	Cursor(name string) (string, error)
+	SetCursor(name, value string) error
+	Cursors() (map[string]string, error)
+
+	Nicks() ([]string, error)
+	RenameNick(from, to string) error
+
+	Clear() error
+	Backup(path string) error
+	Close() error
+}
+
+func trimHistory(h []historyEntry) []historyEntry {
+	if len(h) > maxHistoryEntries {
+		return h[len(h)-maxHistoryEntries:]
+	}
+
+	return h
+}
+
+type memoryStore struct {
+	mu          sync.Mutex
+	users       map[string]userRecord
+	preferences map[string]map[string]string
+	channels    map[string]map[string]string
+	history     map[string][]historyEntry
+	cursors     map[string]string
+}
+
+func copySettings(settings map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range settings {
+		out[k] = v
+	}
+
+	return out
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		users:       map[string]userRecord{},
+		preferences: map[string]map[string]string{},
+		channels:    map[string]map[string]string{},
+		history:     map[string][]historyEntry{},
+		cursors:     map[string]string{},
+	}
+}
+
+func (s *memoryStore) GetUser(nick string) (userRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.users[nick], nil
+}
+
+func (s *memoryStore) UpdateUser(nick string, f func(*userRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.users[nick]
+	f(&rec)
+	s.users[nick] = rec
+
+	return nil
+}
+
+func (s *memoryStore) DeleteUser(nick string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, nick)
+
+	return nil
+}
+
+func (s *memoryStore) Preferences(nick string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copySettings(s.preferences[nick]), nil
+}
+
+func (s *memoryStore) SetPreference(nick, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.preferences[nick] == nil {
+		s.preferences[nick] = map[string]string{}
+	}
+	s.preferences[nick][key] = value
+
+	return nil
+}
+
+func (s *memoryStore) DeletePreference(nick, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.preferences[nick], key)
+	if len(s.preferences[nick]) == 0 {
+		delete(s.preferences, nick)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Channels() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	channels := []string{}
+	for c := range s.channels {
+		channels = append(channels, c)
+	}
+	sort.Strings(channels)
+
+	return channels, nil
+}
+
+func (s *memoryStore) ChannelSettings(channel string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copySettings(s.channels[channel]), nil
+}
+
+func (s *memoryStore) SetChannelSetting(channel, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channels[channel] == nil {
+		s.channels[channel] = map[string]string{}
+	}
+	s.channels[channel][key] = value
+
+	return nil
+}
+
+func (s *memoryStore) DeleteChannelSetting(channel, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.channels[channel], key)
+	if len(s.channels[channel]) == 0 {
+		delete(s.channels, channel)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) AddHistory(nick string, e historyEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history[nick] = trimHistory(append(s.history[nick], e))
+
+	return nil
+}
+
+func (s *memoryStore) History(nick string) ([]historyEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]historyEntry{}, s.history[nick]...), nil
+}
+
+func (s *memoryStore) Cursor(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursors[name], nil
+}
+
+func (s *memoryStore) SetCursor(name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursors[name] = value
+
+	return nil
+}
+
+func (s *memoryStore) Cursors() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copySettings(s.cursors), nil
+}
+
+func (s *memoryStore) Nicks() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := map[string]bool{}
+	for n := range s.users {
+		set[n] = true
+	}
+	for n := range s.preferences {
+		set[n] = true
+	}
+	for n := range s.history {
+		set[n] = true
+	}
+
+	nicks := []string{}
+	for n := range set {
+		nicks = append(nicks, n)
+	}
+	sort.Strings(nicks)
+
+	return nicks, nil
+}
+
+func (s *memoryStore) RenameNick(from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.users[from]; ok {
+		if _, exists := s.users[to]; !exists {
+			s.users[to] = rec
+		}
+		delete(s.users, from)
+	}
+
+	if prefs, ok := s.preferences[from]; ok {
+		if _, exists := s.preferences[to]; !exists {
+			s.preferences[to] = prefs
+		}
+		delete(s.preferences, from)
+	}
+
+	if h, ok := s.history[from]; ok {
+		if _, exists := s.history[to]; !exists {
+			s.history[to] = h
+		}
+		delete(s.history, from)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = map[string]userRecord{}
+	s.preferences = map[string]map[string]string{}
+	s.channels = map[string]map[string]string{}
+	s.history = map[string][]historyEntry{}
+	s.cursors = map[string]string{}
+
+	return nil
+}
+
+func (s *memoryStore) Backup(path string) error {
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
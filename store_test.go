@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	dir := t.TempDir()
+
+	bolt, err := openBoltStore(filepath.Join(dir, "kv.db"))
+	if err != nil {
+		t.Fatalf("failed to open bolt store: %s", err)
+	}
+
+	sqlite, err := openSQLiteStore(filepath.Join(dir, "kv.sqlite"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite store: %s", err)
+	}
+
+	stores := map[string]Store{
+		"memory": newMemoryStore(),
+		"bolt":   bolt,
+		"sqlite": sqlite,
+	}
+
+	t.Cleanup(func() {
+		for _, s := range stores {
+			s.Close()
+		}
+	})
+
+	return stores
+}
+
+func TestStoreUsers(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			rec, err := store.GetUser("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, userRecord{}, rec)
+
+			linkUser(t, store, "nick", "Sharktamer", 119117)
+			assert.Nil(t, store.UpdateUser("nick", func(rec *userRecord) { rec.Token = "gowon-000000" }))
+
+			rec, err = store.GetUser("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, userRecord{User: "Sharktamer", ID: 119117, Token: "gowon-000000"}, rec)
+
+			assert.Nil(t, store.DeleteUser("nick"))
+
+			rec, err = store.GetUser("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, userRecord{}, rec)
+		})
+	}
+}
+
+func TestStorePreferences(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			prefs, err := store.Preferences("nick")
+			assert.Nil(t, err)
+			assert.Empty(t, prefs)
+
+			assert.Nil(t, store.SetPreference("nick", "count", "3"))
+			assert.Nil(t, store.SetPreference("nick", "since", "1w"))
+			assert.Nil(t, store.SetPreference("nick", "count", "2"))
+
+			prefs, err = store.Preferences("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"count": "2", "since": "1w"}, prefs)
+
+			assert.Nil(t, store.DeletePreference("nick", "count"))
+			assert.Nil(t, store.DeletePreference("nick", "missing"))
+
+			prefs, err = store.Preferences("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"since": "1w"}, prefs)
+		})
+	}
+}
+
+func TestStoreHistory(t *testing.T) {
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			expected := []historyEntry{}
+
+			for n := 0; n < maxHistoryEntries+5; n++ {
+				e := historyEntry{Time: start.Add(time.Duration(n) * time.Minute), Command: "last", Args: fmt.Sprint(n)}
+				assert.Nil(t, store.AddHistory("nick", e))
+				expected = append(expected, e)
+			}
+
+			h, err := store.History("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, expected[5:], h)
+
+			h, err = store.History("other")
+			assert.Nil(t, err)
+			assert.Empty(t, h)
+		})
+	}
+}
+
+func TestStoreCursors(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			v, err := store.Cursor("announce")
+			assert.Nil(t, err)
+			assert.Equal(t, "", v)
+
+			assert.Nil(t, store.SetCursor("announce", "1"))
+			assert.Nil(t, store.SetCursor("announce", "2"))
+
+			v, err = store.Cursor("announce")
+			assert.Nil(t, err)
+			assert.Equal(t, "2", v)
+		})
+	}
+}
+
+func TestStoreRenameNick(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			linkUser(t, store, "Alice", "AliceOld", 0)
+			linkUser(t, store, "alice", "AliceNew", 0)
+			assert.Nil(t, store.SetPreference("Alice", "count", "3"))
+			assert.Nil(t, store.AddHistory("Alice", historyEntry{Command: "last"}))
+
+			nicks, err := store.Nicks()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"Alice", "alice"}, nicks)
+
+			assert.Nil(t, store.RenameNick("Alice", "alice"))
+
+			nicks, err = store.Nicks()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"alice"}, nicks)
+
+			rec, err := store.GetUser("alice")
+			assert.Nil(t, err)
+			assert.Equal(t, "AliceNew", rec.User)
+
+			prefs, err := store.Preferences("alice")
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"count": "3"}, prefs)
+
+			h, err := store.History("alice")
+			assert.Nil(t, err)
+			assert.Len(t, h, 1)
+		})
+	}
+}
+
+func TestStoreClear(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			linkUser(t, store, "alice", "Alice", 1)
+			assert.Nil(t, store.SetPreference("bob", "count", "3"))
+			assert.Nil(t, store.AddHistory("carol", historyEntry{Command: "last"}))
+			assert.Nil(t, store.SetCursor("announce", "1"))
+
+			assert.Nil(t, store.Clear())
+
+			nicks, err := store.Nicks()
+			assert.Nil(t, err)
+			assert.Empty(t, nicks)
+
+			cursors, err := store.Cursors()
+			assert.Nil(t, err)
+			assert.Empty(t, cursors)
+		})
+	}
+}
+
+func TestStoreChannelSettings(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			settings, err := store.ChannelSettings("#ra")
+			assert.Nil(t, err)
+			assert.Empty(t, settings)
+
+			assert.Nil(t, store.SetChannelSetting("#ra", "colours", "off"))
+			assert.Nil(t, store.SetChannelSetting("#ra", "detail", "short"))
+			assert.Nil(t, store.SetChannelSetting("#main", "announce", "off"))
+			assert.Nil(t, store.DeleteChannelSetting("#ra", "detail"))
+
+			settings, err = store.ChannelSettings("#ra")
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"colours": "off"}, settings)
+
+			channels, err := store.Channels()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"#main", "#ra"}, channels)
+
+			nicks, err := store.Nicks()
+			assert.Nil(t, err)
+			assert.Empty(t, nicks)
+		})
+	}
+}
+
+func TestStoreBackup(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := map[string]struct {
+		open func(path string) (Store, error)
+	}{
+		"bolt": {
+			open: func(path string) (Store, error) { return openBoltStore(path) },
+		},
+		"sqlite": {
+			open: func(path string) (Store, error) { return openSQLiteStore(path) },
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			store, err := tc.open(filepath.Join(dir, name))
+			assert.Nil(t, err)
+			defer store.Close()
+
+			backup := filepath.Join(dir, name+".bak")
+
+			linkUser(t, store, "alice", "Alice", 1)
+			assert.Nil(t, store.Backup(backup))
+
+			linkUser(t, store, "alice", "Alice", 2)
+			assert.Nil(t, store.Backup(backup))
+
+			assert.Nil(t, store.Clear())
+
+			restored, err := tc.open(backup)
+			assert.Nil(t, err)
+			defer restored.Close()
+
+			rec, err := restored.GetUser("alice")
+			assert.Nil(t, err)
+			assert.Equal(t, userRecord{User: "Alice", ID: 2}, rec)
+		})
+	}
+}
+
+func TestOpenBoltStoreInUse(t *testing.T) {
+	timeout := boltOpenTimeout
+	boltOpenTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { boltOpenTimeout = timeout })
+
+	path := filepath.Join(t.TempDir(), "kv.db")
+
+	store, err := openBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	_, err = openBoltStore(path)
+	assert.ErrorContains(t, err, "in use")
+}
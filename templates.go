@@ -0,0 +1,156 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var defaultTemplateFiles embed.FS
+
+var outputTemplates = template.Must(parseTemplates(defaultTemplateFiles, "templates"))
+
+type capturedOutput struct {
+	Template string
+	Data     any
+}
+
+func templateFuncs(t *template.Template, p Preferences) template.FuncMap {
+	return template.FuncMap{
+		"include": func(name string, data any) (string, error) {
+			return executeTemplate(t, name, data)
+		},
+		"each": func(name string, items any) ([]string, error) {
+			v := reflect.ValueOf(items)
+			if v.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("each needs a list, got %s", v.Kind())
+			}
+
+			out := []string{}
+
+			for i := 0; i < v.Len(); i++ {
+				s, err := executeTemplate(t, name, v.Index(i).Interface())
+				if err != nil {
+					return nil, err
+				}
+
+				out = append(out, s)
+			}
+
+			return out, nil
+		},
+		"colour": func(role, in string) string {
+			return p.Theme.Colour(in, role)
+		},
+		"palette": func(in []string) []string {
+			return p.Theme.List(in)
+		},
+		"short": p.Short,
+		"softcore": func() bool {
+			return !p.HideSoftcore()
+		},
+		"plural":  p.Locale.Plural,
+		"number":  p.Locale.Number,
+		"tr":      p.Locale.Sprintf,
+		"percent": percent,
+		"date": func(t time.Time) string {
+			return formatDate(t, p)
+		},
+		"join": strings.Join,
+		"trimRight": func(cutset, s string) string {
+			return strings.TrimRight(s, cutset)
+		},
+	}
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+
+	return fmt.Sprintf("%d%%", part*100/total)
+}
+
+func parseTemplates(fsys fs.FS, dir string) (*template.Template, error) {
+	root := template.New("").Funcs(templateFuncs(nil, defaultPreferences()))
+
+	if err := addTemplates(root, fsys, dir, false); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func addTemplates(root *template.Template, fsys fs.FS, dir string, override bool) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*"+templateExt))
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range files {
+		name := strings.TrimSuffix(path.Base(fn), templateExt)
+
+		if override && root.Lookup(name) == nil {
+			return fmt.Errorf("unknown template %s", name)
+		}
+
+		b, err := fs.ReadFile(fsys, fn)
+		if err != nil {
+			return err
+		}
+
+		if _, err := root.New(name).Parse(string(b)); err != nil {
+			return fmt.Errorf("unable to parse template %s: %w", fn, err)
+		}
+	}
+
+	return nil
+}
+
+func loadTemplates(dir string) error {
+	t, err := outputTemplates.Clone()
+	if err != nil {
+		return err
+	}
+
+	if err := addTemplates(t, os.DirFS(dir), ".", true); err != nil {
+		return fmt.Errorf("unable to load templates from %s: %w", dir, err)
+	}
+
+	outputTemplates = t
+
+	return nil
+}
+
+func executeTemplate(t *template.Template, name string, data any) (string, error) {
+	var sb strings.Builder
+
+	if err := t.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func renderTemplate(name string, p Preferences, data any) (string, error) {
+	if p.capture != nil {
+		p.capture.Template = name
+		p.capture.Data = data
+	}
+
+	t, err := outputTemplates.Clone()
+	if err != nil {
+		return "", err
+	}
+
+	return executeTemplate(t.Funcs(templateFuncs(t, p)), name, data)
+}
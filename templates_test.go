@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercent(t *testing.T) {
+	cases := map[string]struct {
+		part     int
+		total    int
+		expected string
+	}{
+		"no total": {
+			part:     0,
+			total:    0,
+			expected: "0%",
+		},
+		"rounds down": {
+			part:     2,
+			total:    3,
+			expected: "66%",
+		},
+		"all": {
+			part:     4,
+			total:    4,
+			expected: "100%",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, percent(tc.part, tc.total))
+		})
+	}
+}
+
+func TestRenderTemplateShort(t *testing.T) {
+	p := defaultPreferences()
+	p.Detail = detailShort
+
+	out, err := renderTemplate("achievement", p, Achievement{Title: "achievement", GameTitle: "game", Points: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{cyan}achievement{clear} | {magenta}game{clear} | {green}1 point{clear}", out)
+}
+
+func TestLoadTemplates(t *testing.T) {
+	cases := map[string]struct {
+		dir      string
+		expected string
+		errMsg   string
+	}{
+		"override": {
+			dir:      "override",
+			expected: "user has 10 points",
+		},
+		"unknown template": {
+			dir:    "unknown",
+			errMsg: "unable to load templates from testdata/templates/unknown: unknown template nonsense",
+		},
+		"invalid template": {
+			dir:    "invalid",
+			errMsg: "unable to load templates from testdata/templates/invalid: unable to parse template points.tmpl: template: points:2: unclosed action started at points:1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			defaults := outputTemplates
+			defer func() { outputTemplates = defaults }()
+
+			err := loadTemplates(filepath.Join("testdata", "templates", tc.dir))
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+
+			out, err := renderTemplate("points", defaultPreferences(), struct {
+				User string
+				UserSummary
+			}{"user", UserSummary{TotalPoints: 10}})
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultThemeName      = "default"
+	monochromeThemeName   = "monochrome"
+	highContrastThemeName = "high-contrast"
+)
+
+type Theme struct {
+	Name    string            `json:"-"`
+	Colours map[string]string `json:"colours"`
+	Palette []string          `json:"palette"`
+}
+
+var defaultTheme = &Theme{
+	Name: defaultThemeName,
+	Colours: map[string]string{
+		achievementColour:       "cyan",
+		gameColour:              "magenta",
+		pointsColour:            "green",
+		relaxedPointsColour:     "magenta",
+		hardcoreColour:          "yellow",
+		richPresenceColour:      "yellow",
+		rankColour:              "yellow",
+		awardColour:             "yellow",
+		beatenColour:            "red",
+		completedColour:         "cyan",
+		masteredColour:          "yellow",
+		completionPercentColour: "blue",
+		periodColour:            "orange",
+		onlineColour:            "green",
+		offlineColour:           "red",
+		errorColour:             "red",
+	},
+	Palette: colourNames,
+}
+
+var themes = map[string]*Theme{
+	defaultThemeName: defaultTheme,
+	monochromeThemeName: {
+		Name:    monochromeThemeName,
+		Colours: map[string]string{},
+		Palette: []string{},
+	},
+	highContrastThemeName: {
+		Name: highContrastThemeName,
+		Colours: map[string]string{
+			achievementColour:       "white",
+			gameColour:              "yellow",
+			pointsColour:            "lightgreen",
+			relaxedPointsColour:     "white",
+			hardcoreColour:          "yellow",
+			richPresenceColour:      "white",
+			rankColour:              "white",
+			awardColour:             "yellow",
+			beatenColour:            "white",
+			completedColour:         "white",
+			masteredColour:          "yellow",
+			completionPercentColour: "lightgreen",
+			periodColour:            "white",
+			onlineColour:            "lightgreen",
+			offlineColour:           "red",
+			errorColour:             "red",
+		},
+		Palette: []string{"yellow", "white", "lightgreen"},
+	},
+}
+
+func findTheme(name string) (*Theme, bool) {
+	t, ok := themes[name]
+	return t, ok
+}
+
+func themeSetting(name string) (*Theme, error) {
+	t, ok := findTheme(name)
+	if !ok {
+		return nil, localeErrorf("unknown theme %s, available: %s", name, strings.Join(themeNames(), ", "))
+	}
+
+	return t, nil
+}
+
+func themeNames() []string {
+	names := []string{}
+
+	for n := range themes {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (t *Theme) Colour(in, role string) string {
+	if t == nil {
+		t = defaultTheme
+	}
+
+	c := t.Colours[role]
+	if c == "" {
+		return in
+	}
+
+	return colourString(in, c)
+}
+
+func (t *Theme) List(in []string) []string {
+	if t == nil {
+		t = defaultTheme
+	}
+
+	out := []string{}
+
+	for n, i := range in {
+		if len(t.Palette) == 0 {
+			out = append(out, i)
+			continue
+		}
+
+		out = append(out, colourString(i, t.Palette[n%len(t.Palette)]))
+	}
+
+	return out
+}
+
+func loadThemes(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string]*Theme{}
+
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return fmt.Errorf("unable to read themes from %s: %w", path, err)
+	}
+
+	for name, t := range loaded {
+		if t == nil {
+			return fmt.Errorf("theme %s in %s is empty", name, path)
+		}
+
+		t.Name = name
+
+		if t.Colours == nil {
+			t.Colours = map[string]string{}
+		}
+
+		for role, c := range defaultTheme.Colours {
+			if _, ok := t.Colours[role]; !ok {
+				t.Colours[role] = c
+			}
+		}
+
+		if t.Palette == nil {
+			t.Palette = defaultTheme.Palette
+		}
+
+		themes[name] = t
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThemeList(t *testing.T) {
+	cases := map[string]struct {
+		theme    *Theme
+		in       []string
+		expected []string
+	}{
+		"empty list": {
+			theme:    defaultTheme,
+			in:       []string{},
+			expected: []string{},
+		},
+		"single item": {
+			theme:    defaultTheme,
+			in:       []string{"a"},
+			expected: []string{"{green}a{clear}"},
+		},
+		"two items": {
+			theme:    defaultTheme,
+			in:       []string{"a", "b"},
+			expected: []string{"{green}a{clear}", "{red}b{clear}"},
+		},
+		"nil theme": {
+			theme:    nil,
+			in:       []string{"a"},
+			expected: []string{"{green}a{clear}"},
+		},
+		"monochrome": {
+			theme:    themes[monochromeThemeName],
+			in:       []string{"a", "b"},
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.theme.List(tc.in))
+		})
+	}
+}
+
+func TestThemeColour(t *testing.T) {
+	cases := map[string]struct {
+		theme    string
+		expected string
+	}{
+		"default": {
+			theme:    defaultThemeName,
+			expected: "{magenta}Sonic{clear}",
+		},
+		"monochrome": {
+			theme:    monochromeThemeName,
+			expected: "Sonic",
+		},
+		"high contrast": {
+			theme:    highContrastThemeName,
+			expected: "{yellow}Sonic{clear}",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			theme, ok := findTheme(tc.theme)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, theme.Colour("Sonic", gameColour))
+		})
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	defer delete(themes, "solarised")
+
+	assert.Nil(t, loadThemes(filepath.Join("testdata", "themes", "themes.json")))
+
+	theme, ok := findTheme("solarised")
+	assert.True(t, ok)
+
+	assert.Equal(t, "{teal}Sonic{clear}", theme.Colour("Sonic", gameColour))
+	assert.Equal(t, "10 points", theme.Colour("10 points", pointsColour))
+	assert.Equal(t, "{cyan}Ring{clear}", theme.Colour("Ring", achievementColour))
+	assert.Equal(t, []string{"{teal}a{clear}", "{orange}b{clear}", "{teal}c{clear}"}, theme.List([]string{"a", "b", "c"}))
+
+	assert.Error(t, loadThemes(filepath.Join("testdata", "themes", "missing.json")))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const displayDateFormat = "2006-01-02 15:04 MST"
+
+type raTime struct {
+	time.Time
+}
+
+func parseRATime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation(timeDateFormat, s, time.UTC); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse retroachievements time %q", s)
+	}
+
+	return t.UTC(), nil
+}
+
+func (t *raTime) UnmarshalJSON(b []byte) error {
+	var s *string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	if s == nil {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	parsed, err := parseRATime(*s)
+	if err != nil {
+		return err
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+func relativeTime(t time.Time, l *Locale) string {
+	d := now().Sub(t)
+
+	switch {
+	case d < time.Minute:
+		return l.Translate("just now")
+	case d < time.Hour:
+		return l.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return l.Sprintf("%dh ago", int(d/time.Hour))
+	}
+
+	return l.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
+
+func formatDate(t time.Time, p Preferences) string {
+	if p.Dates == datesAbsolute {
+		loc := p.Timezone
+		if loc == nil {
+			loc = time.UTC
+		}
+
+		return t.In(loc).Format(displayDateFormat)
+	}
+
+	return relativeTime(t, p.Locale)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRATime(t *testing.T) {
+	cases := map[string]struct {
+		in       string
+		expected time.Time
+		errMsg   string
+	}{
+		"date time": {
+			in:       "2024-08-29 01:42:58",
+			expected: time.Date(2024, 8, 29, 1, 42, 58, 0, time.UTC),
+		},
+		"rfc3339 with offset": {
+			in:       "2022-10-23T06:09:38+02:00",
+			expected: time.Date(2022, 10, 23, 4, 9, 38, 0, time.UTC),
+		},
+		"empty": {
+			in:       "",
+			expected: time.Time{},
+		},
+		"invalid": {
+			in:     "yesterday",
+			errMsg: `unable to parse retroachievements time "yesterday"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := parseRATime(tc.in)
+
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestRATimeUnmarshalJSON(t *testing.T) {
+	var j struct {
+		Date    raTime `json:"Date"`
+		Missing raTime `json:"Missing"`
+	}
+
+	err := json.Unmarshal([]byte(`{"Date": "2024-08-29 01:42:58", "Missing": null}`), &j)
+
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 8, 29, 1, 42, 58, 0, time.UTC), j.Date.Time)
+	assert.True(t, j.Missing.IsZero())
+}
+
+func TestRelativeTime(t *testing.T) {
+	current := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	cases := map[string]struct {
+		in       time.Time
+		expected string
+	}{
+		"seconds": {
+			in:       current.Add(-30 * time.Second),
+			expected: "just now",
+		},
+		"minutes": {
+			in:       current.Add(-5 * time.Minute),
+			expected: "5m ago",
+		},
+		"hours": {
+			in:       current.Add(-3*time.Hour - 20*time.Minute),
+			expected: "3h ago",
+		},
+		"days": {
+			in:       current.Add(-50 * time.Hour),
+			expected: "2d ago",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, relativeTime(tc.in, nil))
+		})
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	fixNow(t, "2024-08-31 17:00:00")
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	in := time.Date(2024, 8, 29, 1, 42, 58, 0, time.UTC)
+
+	cases := map[string]struct {
+		dates    string
+		timezone *time.Location
+		expected string
+	}{
+		"relative": {
+			dates:    datesRelative,
+			timezone: time.UTC,
+			expected: "2d ago",
+		},
+		"absolute utc": {
+			dates:    datesAbsolute,
+			timezone: time.UTC,
+			expected: "2024-08-29 01:42 UTC",
+		},
+		"absolute timezone": {
+			dates:    datesAbsolute,
+			timezone: tokyo,
+			expected: "2024-08-29 10:42 JST",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := defaultPreferences()
+			p.Dates = tc.dates
+			p.Timezone = tc.timezone
+
+			assert.Equal(t, tc.expected, formatDate(in, p))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const tokenPrefix = "gowon-"
+
+var newToken = func() (string, error) {
+	b := make([]byte, 3)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+func verifyHandler(ctx *commandContext) (string, error) {
+	nick := ctx.msg.Nick
+	key := ctx.nickKey(nick)
+
+	rec, err := ctx.store.GetUser(key)
+	if err != nil {
+		return "", err
+	}
+
+	if rec.User == "" {
+		return ctx.sprintf("Error: link a retroachievements user with set before verifying")
+	}
+
+	if rec.Verified() {
+		return ctx.sprintf("%s is already verified as %s", nick, rec.User)
+	}
+
+	if rec.Token == "" {
+		token, err := newToken()
+		if err != nil {
+			return "", err
+		}
+
+		err = ctx.store.UpdateUser(key, func(rec *userRecord) {
+			rec.Token = token
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return ctx.sprintf("add %s to %s's retroachievements profile motto, then run verify again", token, rec.User)
+	}
+
+	profile, err := raUserProfile(ctx.client, rec.User)
+	if err != nil {
+		return "", err
+	}
+
+	if profile.ID != rec.ID {
+		return ctx.sprintf("Error: %s no longer matches the linked account, link it again with set", rec.User)
+	}
+
+	if !strings.Contains(profile.Motto, rec.Token) {
+		return ctx.sprintf("%s not found in %s's motto, add it to the profile motto and run verify again", rec.Token, rec.User)
+	}
+
+	err = ctx.store.UpdateUser(key, func(rec *userRecord) {
+		rec.Token = ""
+		rec.VerifiedID = rec.ID
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.sprintf("verified %s as %s, the token can now be removed from the motto", nick, rec.User)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyHandler(t *testing.T) {
+	cases := map[string]struct {
+		user     string
+		id       int
+		token    string
+		expected string
+		verified bool
+	}{
+		"not linked": {
+			expected: "Error: link a retroachievements user with set before verifying",
+		},
+		"issue token": {
+			user:     "Sharktamer",
+			id:       119117,
+			expected: "add gowon-000000 to Sharktamer's retroachievements profile motto, then run verify again",
+		},
+		"token in motto": {
+			user:     "Sharktamer",
+			id:       119117,
+			token:    "gowon-3f9a1c",
+			expected: "verified nick as Sharktamer, the token can now be removed from the motto",
+			verified: true,
+		},
+		"token not in motto": {
+			user:     "Sharktamer",
+			id:       119117,
+			token:    "gowon-ffffff",
+			expected: "gowon-ffffff not found in Sharktamer's motto, add it to the profile motto and run verify again",
+		},
+		"account changed": {
+			user:     "Sharktamer",
+			id:       1,
+			token:    "gowon-3f9a1c",
+			expected: "Error: Sharktamer no longer matches the linked account, link it again with set",
+		},
+	}
+
+	generate := newToken
+	t.Cleanup(func() { newToken = generate })
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			newToken = func() (string, error) { return "gowon-000000", nil }
+
+			store := newMemoryStore()
+			json := openTestFile(t, "API_GetUserProfile", "profile.json")
+
+			if tc.user != "" {
+				linkUser(t, store, "nick", tc.user, tc.id)
+			}
+
+			if tc.token != "" {
+				assert.Nil(t, store.UpdateUser("nick", func(rec *userRecord) { rec.Token = tc.token }))
+			}
+
+			client := req.C()
+			httpmock.ActivateNonDefault(client.GetClient())
+			httpmock.RegisterResponder("GET", raUserProfileURL, func(request *http.Request) (*http.Response, error) {
+				resp := httpmock.NewBytesResponse(http.StatusOK, json)
+				return resp, nil
+			})
+
+			out, err := verifyHandler(testContext(client, store, "nick"))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out)
+
+			rec, err := store.GetUser("nick")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.verified, rec.Verified())
+		})
+	}
+}
+
+func TestUserRecordVerified(t *testing.T) {
+	rec := userRecord{User: "Sharktamer", ID: 119117, VerifiedID: 119117}
+	assert.True(t, rec.Verified())
+
+	rec.ID = 1
+	assert.False(t, rec.Verified())
+
+	assert.False(t, userRecord{}.Verified())
+}